@@ -35,28 +35,80 @@ func main() {
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 
-	appSettings, credentials := core.InitializeApp(os.Args)
+	appSettings, credentials, command := core.InitializeApp(os.Args)
 	app := core.CreateTradeApp(credentials)
+	if core.IsDiagnosticsCommand(command) {
+		err := app.RunDiagnostics(appSettings)
+		app.Shutdown()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if len(command) > 0 {
+		if core.CommandNeedsSession(command) {
+			core.StartServices(app, appSettings)
+		}
+		go func() {
+			for sig := range signalChannel {
+				if sig == os.Interrupt && app.InterruptStream() {
+					continue
+				}
+				app.Shutdown()
+				if s, ok := sig.(syscall.Signal); ok {
+					os.Exit(128 + int(s))
+				}
+				os.Exit(1)
+			}
+		}()
+		err := app.RunCommand(command)
+		app.Shutdown()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	core.StartServices(app, appSettings)
 
 	reader := bufio.NewReader(os.Stdin)
-	done := make(chan struct{})
+	quit := make(chan struct{})
 
 	go func() {
 		for {
-			core.DisplayMainMenu()
-			input, err := core.GetUserInput(reader)
+			core.DisplayMainMenu(app)
+			input, err := app.ReadMainMenuChoice(reader)
 			if err != nil {
 				fmt.Println("Error reading input:", err)
 				continue
 			}
 
-			core.HandleMainMenuChoice(input, app, reader)
+			if core.HandleMainMenuChoice(input, app, reader) {
+				close(quit)
+				return
+			}
 		}
 	}()
 
-	<-signalChannel
-	close(done)
-	fmt.Println("Interrupt received, shutting down...")
+	exitCode := 0
+wait:
+	for {
+		select {
+		case sig := <-signalChannel:
+			if sig == os.Interrupt && app.InterruptStream() {
+				continue
+			}
+			fmt.Println("Interrupt received, shutting down...")
+			if s, ok := sig.(syscall.Signal); ok {
+				exitCode = 128 + int(s)
+			}
+			break wait
+		case <-quit:
+			break wait
+		}
+	}
 
+	app.Shutdown()
+	os.Exit(exitCode)
 }
@@ -1,9 +1,68 @@
 package config
 
+import "github.com/shopspring/decimal"
+
+type DisplayPrecision struct {
+	Price    int32
+	Quantity int32
+}
+
+type OrderMinimum struct {
+	BaseQuantity decimal.Decimal
+	Notional     decimal.Decimal
+}
+
 type Config struct {
+	Version int
+
 	Passphrase   string
 	ApiKey       string
 	ApiSecret    string
 	PortfolioId  string
 	SvcAccountId string
+
+	RestBaseUrl           string
+	WebSocketUri          string
+	PriceFeedUrl          string
+	ProxyUrl              string
+	CaBundleFile          string
+	SupportedProducts     []string
+	FiatAssets            []string
+	MaxOrderSize          string
+	ProductMaxOrderSizes  map[string]decimal.Decimal
+	MinOrderSizes         map[string]OrderMinimum
+	CostBasis             map[string]decimal.Decimal
+	DailyNotionalLimit    string
+	ConfirmThreshold      string
+	FeeRateBps            string
+	MaxSlippagePct        string
+	MaxDepthFraction      string
+	FfpThresholds         map[string]float64
+	BookPrecision         map[string]DisplayPrecision
+	BookMaxDepth          int
+	MaxPriceAgeSeconds    int
+	RequestTimeoutSeconds int
+	MaxRetries            int
+	RestRateLimit         float64
+	PriceFetchSeconds     float64
+	RestBurst             int
+	RetryPostRequests     bool
+	OrdersPageSize        int
+	OrdersRefreshSeconds  int
+	Watchlist             []string
+	WatchRefreshSeconds   int
+	AlertBell             bool
+	BalanceCacheSeconds   int
+	AckTimeoutSeconds     int
+	MaxClockDriftSeconds  int
+	FixLivenessSeconds    int
+	WsReadTimeoutSeconds  int
+	FixResetOnStale       bool
+	Debug                 bool
+	DisplayUtc            bool
+	JsonLogs              bool
+	OutputFormat          string
+	AuditLogFile          string
+	MetricsEnabled        bool
+	MetricsPort           int
 }
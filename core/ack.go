@@ -0,0 +1,115 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultAckTimeout = 5 * time.Second
+
+type pendingOrder struct {
+	Product string
+	Side    string
+	SentAt  time.Time
+	Warned  bool
+	// acked receives the ExecType of the first execution report for the order.
+	acked chan string
+}
+
+func (app *TradeApp) addPendingOrder(clOrdId string, params parsedTradeParams) <-chan string {
+	app.pendingMutex.Lock()
+	defer app.pendingMutex.Unlock()
+	acked := make(chan string, 1)
+	app.pendingOrders[clOrdId] = pendingOrder{
+		Product: params.Product,
+		Side:    params.Side,
+		SentAt:  time.Now(),
+		acked:   acked,
+	}
+	return acked
+}
+
+func (app *TradeApp) removePendingOrder(clOrdId string) (pendingOrder, bool) {
+	app.pendingMutex.Lock()
+	defer app.pendingMutex.Unlock()
+	order, ok := app.pendingOrders[clOrdId]
+	if ok {
+		delete(app.pendingOrders, clOrdId)
+	}
+	return order, ok
+}
+
+func (app *TradeApp) acknowledgeOrder(clOrdId, orderId, execType string) {
+	order, ok := app.removePendingOrder(clOrdId)
+	if !ok {
+		return
+	}
+	order.acked <- execType
+
+	if execType == FixExecTypeNew {
+		latency := time.Since(order.SentAt).Round(time.Millisecond)
+		app.logger.Log(LogLevelInfo, "order_acknowledged", fmt.Sprintf("Order acknowledged: %s -> %s (%s)", clOrdId, orderId, latency), LogFields{
+			"cl_ord_id":  clOrdId,
+			"order_id":   orderId,
+			"latency_ms": latency.Milliseconds(),
+		})
+	}
+}
+
+func (app *TradeApp) checkPendingOrders(now time.Time) {
+	timeout := app.ackTimeout()
+
+	app.pendingMutex.Lock()
+	defer app.pendingMutex.Unlock()
+	for clOrdId, order := range app.pendingOrders {
+		if order.Warned || now.Sub(order.SentAt) < timeout {
+			continue
+		}
+		app.logger.Log(LogLevelWarn, "order_ack_timeout", fmt.Sprintf("Warning: No acknowledgement after %s for %s %s order %s", timeout, order.Product, order.Side, clOrdId), LogFields{
+			"cl_ord_id": clOrdId,
+			"product":   order.Product,
+			"side":      order.Side,
+		})
+		order.Warned = true
+		app.pendingOrders[clOrdId] = order
+	}
+}
+
+func (app *TradeApp) ackTimeout() time.Duration {
+	if app.AckTimeoutSeconds <= 0 {
+		return DefaultAckTimeout
+	}
+	return time.Duration(app.AckTimeoutSeconds) * time.Second
+}
+
+func StartAckMonitor(app *TradeApp, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-app.ctx.Done():
+				return
+			case now := <-ticker.C:
+				app.checkPendingOrders(now)
+			}
+		}
+	}()
+}
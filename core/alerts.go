@@ -0,0 +1,224 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AlertAbove  = ">"
+	AlertBelow  = "<"
+	ArgRepeat   = "-repeat"
+	terminalBel = "\a"
+)
+
+type priceAlert struct {
+	Id      int
+	Product string
+	Above   bool
+	Price   decimal.Decimal
+	Repeat  bool
+	// Armed is cleared when a repeating alert fires and set again once the price moves back across the threshold.
+	Armed bool
+}
+
+func (a priceAlert) String() string {
+	condition := AlertBelow
+	if a.Above {
+		condition = AlertAbove
+	}
+	mode := "once"
+	if a.Repeat {
+		mode = "repeating"
+	}
+	return fmt.Sprintf("%s %s %s (%s)", a.Product, condition, a.Price.String(), mode)
+}
+
+func (a priceAlert) crossed(price decimal.Decimal) bool {
+	if a.Above {
+		return price.GreaterThan(a.Price)
+	}
+	return price.LessThan(a.Price)
+}
+
+type alertStore struct {
+	mutex  sync.Mutex
+	alerts []priceAlert
+	nextId int
+}
+
+var priceAlerts = &alertStore{nextId: 1}
+
+func addPriceAlert(alert priceAlert) priceAlert {
+	priceAlerts.mutex.Lock()
+	defer priceAlerts.mutex.Unlock()
+	alert.Id = priceAlerts.nextId
+	alert.Armed = true
+	priceAlerts.nextId++
+	priceAlerts.alerts = append(priceAlerts.alerts, alert)
+	return alert
+}
+
+func removePriceAlert(id int) bool {
+	priceAlerts.mutex.Lock()
+	defer priceAlerts.mutex.Unlock()
+	for i, alert := range priceAlerts.alerts {
+		if alert.Id == id {
+			priceAlerts.alerts = append(priceAlerts.alerts[:i], priceAlerts.alerts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func listPriceAlerts() []priceAlert {
+	priceAlerts.mutex.Lock()
+	defer priceAlerts.mutex.Unlock()
+	return append([]priceAlert{}, priceAlerts.alerts...)
+}
+
+// evaluateAlerts runs on the price fetching goroutine and returns the alerts triggered by the latest price.
+func evaluateAlerts(productId string, price decimal.Decimal) []priceAlert {
+	priceAlerts.mutex.Lock()
+	defer priceAlerts.mutex.Unlock()
+
+	var triggered []priceAlert
+	remaining := priceAlerts.alerts[:0]
+	for _, alert := range priceAlerts.alerts {
+		if alert.Product == productId {
+			crossed := alert.crossed(price)
+			if crossed && alert.Armed {
+				triggered = append(triggered, alert)
+				if !alert.Repeat {
+					continue
+				}
+				alert.Armed = false
+			} else if !crossed {
+				alert.Armed = true
+			}
+		}
+		remaining = append(remaining, alert)
+	}
+	priceAlerts.alerts = remaining
+	return triggered
+}
+
+func (app *TradeApp) checkPriceAlerts(productId string, price decimal.Decimal) {
+	for _, alert := range evaluateAlerts(productId, price) {
+		message := fmt.Sprintf("ALERT: %s, last price %s", alert.String(), price.String())
+		if app.AlertBell {
+			fmt.Print(terminalBel)
+		}
+		app.logger.Log(LogLevelWarn, "price_alert", message, LogFields{
+			"alert_id": alert.Id,
+			"product":  alert.Product,
+			"price":    price.String(),
+			"repeat":   alert.Repeat,
+		})
+	}
+}
+
+func (app *TradeApp) parsePriceAlert(input string) (priceAlert, error) {
+	parts := strings.Fields(input)
+	if len(parts) != 3 && !(len(parts) == 4 && strings.ToLower(parts[3]) == ArgRepeat) {
+		return priceAlert{}, fmt.Errorf("expected 'product > price' or 'product < price', optionally followed by %s", ArgRepeat)
+	}
+
+	product, err := normalizeProduct(parts[0])
+	if err != nil {
+		return priceAlert{}, err
+	}
+	alert := priceAlert{Product: product, Repeat: len(parts) == 4}
+	if !app.isSupportedProduct(alert.Product) {
+		return priceAlert{}, fmt.Errorf("%s is not priced, add it to SupportedProducts in creds.json", alert.Product)
+	}
+
+	switch parts[1] {
+	case AlertAbove:
+		alert.Above = true
+	case AlertBelow:
+	default:
+		return priceAlert{}, fmt.Errorf("invalid condition %q, expected %s or %s", parts[1], AlertAbove, AlertBelow)
+	}
+
+	price, err := decimal.NewFromString(parts[2])
+	if err != nil || !price.IsPositive() {
+		return priceAlert{}, fmt.Errorf("invalid price %q", parts[2])
+	}
+	alert.Price = price
+	return alert, nil
+}
+
+func (app *TradeApp) isSupportedProduct(productId string) bool {
+	for _, product := range app.SupportedProducts {
+		if product == productId {
+			return true
+		}
+	}
+	return false
+}
+
+func (app *TradeApp) alertsMode(reader *bufio.Reader) {
+	for {
+		fmt.Println(LineSpacer)
+		alerts := listPriceAlerts()
+		if len(alerts) == 0 {
+			fmt.Println("No price alerts set.")
+		}
+		for _, alert := range alerts {
+			fmt.Printf(Blue+"%d. %s\n"+Reset, alert.Id, alert.String())
+		}
+		fmt.Printf("Enter an alert (e.g., 'eth-usd > 2000' or 'eth-usd < 1500 %s'), '%s n' to remove alert n, or '%s' to return to main menu:\n", ArgRepeat, ArgRemove, SelectExit)
+
+		input, err := GetUserInput(reader)
+		if err != nil {
+			fmt.Println("Error reading input:", err)
+			continue
+		}
+
+		parts := strings.Fields(input)
+		switch {
+		case len(parts) == 0:
+			continue
+		case parts[0] == SelectExit:
+			return
+		case parts[0] == ArgRemove:
+			id := 0
+			if len(parts) == 2 {
+				id, _ = strconv.Atoi(parts[1])
+			}
+			if !removePriceAlert(id) {
+				fmt.Println("Invalid alert number.")
+			}
+		default:
+			alert, err := app.parsePriceAlert(input)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			alert = addPriceAlert(alert)
+			fmt.Printf(Green+"Added alert %d: %s\n"+Reset, alert.Id, alert.String())
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type auditLog struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file}, nil
+}
+
+// Record appends one JSON line and syncs it to disk before returning. A nil auditLog is a no-op.
+func (a *auditLog) Record(event string, fields LogFields) {
+	if a == nil {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+2)
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["event"] = event
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf(Red+"Failed to encode audit entry: %v\n"+Reset, err)
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		fmt.Printf(Red+"Failed to write audit log: %v\n"+Reset, err)
+		return
+	}
+	if err := a.file.Sync(); err != nil {
+		fmt.Printf(Red+"Failed to sync audit log: %v\n"+Reset, err)
+	}
+}
+
+func (a *auditLog) Close() {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.file.Close()
+}
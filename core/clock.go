@@ -0,0 +1,84 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultMaxClockDrift = 5 * time.Second
+
+func (app *TradeApp) maxClockDrift() time.Duration {
+	if app.MaxClockDriftSeconds <= 0 {
+		return DefaultMaxClockDrift
+	}
+	return time.Duration(app.MaxClockDriftSeconds) * time.Second
+}
+
+func (app *TradeApp) measureClockDrift(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.requestTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, app.restBaseUrl(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := time.Now()
+	resp, err := app.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("server did not return a usable Date header: %w", err)
+	}
+
+	localTime := sent.Add(received.Sub(sent) / 2)
+	return localTime.Sub(serverTime), nil
+}
+
+func (app *TradeApp) CheckClockDrift() {
+	drift, err := app.measureClockDrift(app.ctx)
+	if err != nil {
+		log.Printf(Yellow+"Warning: Unable to check clock drift: %v"+Reset, err)
+		return
+	}
+
+	tolerance := app.maxClockDrift()
+	if drift > tolerance || drift < -tolerance {
+		log.Printf(Yellow+"Warning: Local clock is %s off from Coinbase (tolerance %s). FIX logon may be rejected; sync your system clock."+Reset, drift.Round(time.Millisecond), tolerance)
+		return
+	}
+	app.debugf("Clock drift %s is within tolerance %s", drift.Round(time.Millisecond), tolerance)
+}
+
+func clockHint(reason string) string {
+	lower := strings.ToLower(reason)
+	if strings.Contains(lower, "time") || strings.Contains(lower, "clock") || strings.Contains(lower, "expired") {
+		return reason + " (this usually means the local clock is out of sync, check your system time)"
+	}
+	return reason
+}
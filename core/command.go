@@ -0,0 +1,171 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CommandBalance     = "balance"
+	CommandBalances    = "balances"
+	CommandOpenOrders  = "open-orders"
+	CommandOrders      = "orders"
+	CommandOrder       = "order"
+	CommandCancel      = "cancel"
+	CommandDiagnostics = "diagnostics"
+	CommandReplay      = "replay"
+)
+
+// CommandNeedsSession reports whether a command has to log on to FIX before it can run.
+func IsDiagnosticsCommand(args []string) bool {
+	return len(args) > 0 && strings.ToLower(args[0]) == CommandDiagnostics
+}
+
+func CommandNeedsSession(args []string) bool {
+	return len(args) > 0 && strings.ToLower(args[0]) == CommandOrder
+}
+
+func (app *TradeApp) jsonOutput() bool {
+	return app.OutputFormat == FormatJson
+}
+
+func writeJson(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// RunCommand runs a single non-interactive command and returns once it completes.
+func (app *TradeApp) RunCommand(args []string) error {
+	switch strings.ToLower(args[0]) {
+	case CommandBalance:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s <asset>", CommandBalance)
+		}
+		balance, err := app.GetAssetBalance(args[1])
+		if err != nil {
+			return err
+		}
+		if app.jsonOutput() {
+			return writeJson(balance)
+		}
+		fmt.Printf("Amount: %s\nHolds: %s\nWithdrawable Amount: %s\nFiat Amount: %s\n", balance.Amount, balance.Holds, balance.WithdrawableAmount, balance.FiatAmount)
+		return nil
+	case CommandBalances:
+		return app.ViewAllBalances()
+	case CommandOpenOrders:
+		filter, err := parseOrderFilter(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		orders, err := app.fetchOpenOrders(app.ctx)
+		if err != nil {
+			return err
+		}
+		if app.jsonOutput() {
+			return writeJson(filter.apply(orders))
+		}
+		app.printOrdersTable(filter.apply(orders), false)
+		return nil
+	case CommandOrders:
+		filter, err := parseOrderFilter(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		orders, _, err := app.fetchOrdersPage("")
+		if err != nil {
+			return err
+		}
+		if app.jsonOutput() {
+			return writeJson(filter.apply(orders))
+		}
+		app.printOrdersTable(filter.apply(orders), true)
+		return nil
+	case CommandOrder:
+		return app.runOrderCommand(args[1:])
+	case CommandCancel:
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s <order_id>", CommandCancel)
+		}
+		if err := app.CancelOrder(args[1], ""); err != nil {
+			return err
+		}
+		return app.confirmCancel(args[1], "")
+	case CommandReplay:
+		return app.runReplayCommand(args[1:])
+	}
+	return fmt.Errorf("unknown command %q, expected one of %s, %s, %s, %s, %s, %s or %s", args[0], CommandBalance, CommandBalances, CommandOpenOrders, CommandOrders, CommandOrder, CommandCancel, CommandReplay)
+}
+
+func (app *TradeApp) runReplayCommand(args []string) error {
+	usage := fmt.Errorf("usage: %s <file> <n> [%s]", CommandReplay, ArgFast)
+	if len(args) < 2 || len(args) > 3 {
+		return usage
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > 9 {
+		return usage
+	}
+	realtime := true
+	if len(args) == 3 {
+		if strings.ToLower(args[2]) != ArgFast {
+			return usage
+		}
+		realtime = false
+	}
+	return app.ReplayOrderBook(args[0], n, realtime)
+}
+
+func (app *TradeApp) runOrderCommand(args []string) error {
+	params, limitPrice, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+	if err := app.validateMinimumSize(params, limitPrice); err != nil {
+		return err
+	}
+	if !app.validateOrderAgainstFFP(params, limitPrice) {
+		return fmt.Errorf("order rejected by fat finger protection")
+	}
+	if threshold := app.confirmThreshold(); threshold.IsPositive() {
+		if notional, ok := app.estimateNotional(params, limitPrice); ok && notional.GreaterThan(threshold) {
+			return fmt.Errorf("order notional %s exceeds ConfirmThreshold %s, submit it interactively", notional.StringFixed(2), threshold.String())
+		}
+	}
+
+	clOrdId, acked, err := app.sendTrade(params, limitPrice, app.SessionId)
+	if err != nil {
+		app.logOrderFailure(params, err)
+		return err
+	}
+
+	select {
+	case execType := <-acked:
+		if execType == FixExecTypeReject {
+			return fmt.Errorf("order %s rejected", clOrdId)
+		}
+		return nil
+	case <-time.After(app.ackTimeout()):
+		return fmt.Errorf("no acknowledgement for order %s after %s", clOrdId, app.ackTimeout())
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+type completer func(previous []string) []string
+
+var tradeFlags = []string{"-p", ArgOco, ArgStop, ArgLinkedOco, ArgTrail, ArgTwap, ArgIceberg, ArgGtt}
+
+func staticCompleter(candidates ...string) completer {
+	return func(previous []string) []string {
+		if len(previous) > 0 {
+			return nil
+		}
+		return candidates
+	}
+}
+
+func mainMenuCompleter() completer {
+	return staticCompleter(SelectTrade, SelectMarket, SelectOrder, SelectOco, SelectWatchlist, SelectAlerts, SelectExit)
+}
+
+func orderManagerCompleter() completer {
+	choices := []string{SelectExit}
+	for choice := SelectOpenOrders; choice <= SelectWallets; choice++ {
+		choices = append(choices, strconv.Itoa(choice))
+	}
+	return staticCompleter(choices...)
+}
+
+func (app *TradeApp) tradeInputCompleter() completer {
+	return func(previous []string) []string {
+		switch len(previous) {
+		case 0:
+			candidates := []string{"h", SelectExit, ArgPanic, ArgAgain, ArgCancelTwap, ArgRefreshCache}
+			for _, product := range app.SupportedProducts {
+				candidates = append(candidates, strings.ToLower(product))
+			}
+			return candidates
+		case 1:
+			return []string{ArgMarket, ArgLimit}
+		case 2:
+			return []string{ArgBuy, ArgSell}
+		}
+		return append([]string{ArgGtc, ArgIoc, ArgFok}, tradeFlags...)
+	}
+}
+
+func completeLine(line string, pos int, complete completer) (string, int, bool) {
+	head, tail := line[:pos], line[pos:]
+	fields := strings.Split(head, " ")
+	current := fields[len(fields)-1]
+
+	var matches []string
+	for _, candidate := range complete(fields[:len(fields)-1]) {
+		if strings.HasPrefix(candidate, strings.ToLower(current)) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		completion += " "
+	}
+	if completion == current {
+		return "", 0, false
+	}
+
+	head = head[:len(head)-len(current)] + completion
+	return head + tail, len(head), true
+}
+
+func commonPrefix(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	first, last := sorted[0], sorted[len(sorted)-1]
+
+	i := 0
+	for i < len(first) && i < len(last) && first[i] == last[i] {
+		i++
+	}
+	return first[:i]
+}
+
+func (app *TradeApp) readInput(reader *bufio.Reader, complete completer) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if reader.Buffered() > 0 || !term.IsTerminal(fd) {
+		return GetUserInput(reader)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return GetUserInput(reader)
+	}
+	defer term.Restore(fd, state)
+
+	if app.terminal == nil {
+		app.terminal = term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, "> ")
+	}
+	app.terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completeLine(line, pos, complete)
+	}
+
+	input, err := app.terminal.ReadLine()
+	if err == io.EOF {
+		term.Restore(fd, state)
+		if process, findErr := os.FindProcess(os.Getpid()); findErr == nil {
+			process.Signal(os.Interrupt)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+func (app *TradeApp) ReadMainMenuChoice(reader *bufio.Reader) (string, error) {
+	return app.readInput(reader, mainMenuCompleter())
+}
@@ -18,23 +18,33 @@ package core
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/coinbase-samples/trader-shell-go/config"
 	"github.com/shopspring/decimal"
+	"golang.org/x/time/rate"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/quickfixgo/quickfix"
+	"golang.org/x/term"
 )
 
 const (
-	credsFile     = "creds.json"
-	priceFetchGap = 10 * time.Second
+	credsFile        = "creds.json"
+	credsFileEnv     = "CB_CREDS_FILE"
+	ackCheckGap      = 1 * time.Second
+	logonTimeout     = 30 * time.Second
+	livenessCheckGap = 5 * time.Second
 )
 
 var MaxOrderSize = decimal.NewFromFloat(50000.0)
@@ -42,47 +52,107 @@ var MaxOrderSize = decimal.NewFromFloat(50000.0)
 type TradeApp struct {
 	*quickfix.MessageRouter
 	config.Config
-	SessionId       quickfix.SessionID
-	OrderBook       *OrderBookProcessor
-	disconnect      bool
-	FirstPrint      bool
-	MaxOrderSize    decimal.Decimal
-	LogonChannel    chan bool
-	stopOrdersMutex sync.Mutex
+	SessionId         quickfix.SessionID
+	OrderBooks        map[string]*OrderBookProcessor
+	FirstPrint        bool
+	bookLines         int
+	vwapQuery         *vwapQuery
+	bookGroupSize     float64
+	feedStats         *feedStats
+	MaxOrderSize      decimal.Decimal
+	LogonChannel      chan bool
+	stopOrdersMutex   sync.Mutex
+	dailyNotional     dailyNotionalTracker
+	productsMutex     sync.Mutex
+	products          map[string]Product
+	pendingMutex      sync.Mutex
+	pendingOrders     map[string]pendingOrder
+	sessionMutex      sync.Mutex
+	loggedOn          bool
+	hasLoggedOn       bool
+	lastFixMessage    time.Time
+	testRequestSentAt time.Time
+	livenessResetSent bool
+	logonFailures     chan error
+	logger            Logger
+	restLimiter       *rate.Limiter
+	audit             *auditLog
+	terminal          *term.Terminal
+	lastOrder         *lastOrder
+	ctx               context.Context
+	cancel            context.CancelFunc
+	initiator         *quickfix.Initiator
+	wsMutex           sync.Mutex
+	wsConn            *websocket.Conn
+	stopStream        func()
+	stopPriceFetching func()
+	priceLimiter      *rate.Limiter
+	prices            *priceStore
+	balances          *balanceStore
+	twapMutex         sync.Mutex
+	twapSchedules     map[int]context.CancelFunc
+	nextTwapId        int
+	icebergMutex      sync.Mutex
+	icebergs          map[string]*icebergOrder
+	nextIcebergId     int
+	httpClient        *http.Client
+	wsDialer          *websocket.Dialer
 }
 
-var supportedProducts = []string{
+var defaultFiatAssets = []string{"USD", "EUR", "GBP"}
+
+var defaultSupportedProducts = []string{
 	"ETH-USD",
 	"LTC-USD",
 }
 var stopOrders []stopOrder
 
-func DisplayMainMenu() {
+func DisplayMainMenu(app *TradeApp) {
 	fmt.Println(LineSpacer)
+	if app.IsLoggedOn() {
+		if since, ok := app.SinceLastFixMessage(); ok {
+			fmt.Printf(Green+"FIX session: Connected (last message %s ago)\n"+Reset, since.Round(time.Second))
+		} else {
+			fmt.Println(Green + "FIX session: Connected" + Reset)
+		}
+	} else {
+		fmt.Println(Red + "FIX session: Not connected" + Reset)
+	}
 	fmt.Println("Choose an option:")
 	fmt.Printf("%d. Trade input\n", TradeInput)
 	fmt.Printf("%d. Market data\n", MarketData)
 	fmt.Printf("%d. Order manager\n", OrderManager)
 	fmt.Printf("%d. OCO manager\n", OCOManager)
+	fmt.Printf("%d. Watchlist\n", WatchlistMenu)
+	fmt.Printf("%d. Price alerts\n", AlertsMenu)
 	fmt.Printf("Type '%s' to quit.\n", SelectExit)
 }
 
-func HandleMainMenuChoice(choice string, app *TradeApp, reader *bufio.Reader) {
+func HandleMainMenuChoice(choice string, app *TradeApp, reader *bufio.Reader) bool {
 	switch choice {
 	case SelectTrade:
+		if !app.IsLoggedOn() {
+			fmt.Println(Red + "Not connected. Trade input is unavailable until the FIX session reconnects." + Reset)
+			return false
+		}
 		app.tradeInputMode(reader)
 	case SelectMarket:
 		app.MarketDataMode(reader)
 	case SelectOrder:
 		app.orderManagerMode(reader)
 	case SelectOco:
-		app.displayStopOrders()
+		app.displayStopOrders(reader)
+	case SelectWatchlist:
+		app.watchlistMode(reader)
+	case SelectAlerts:
+		app.alertsMode(reader)
 	case SelectExit:
 		fmt.Println("Exiting...")
-		os.Exit(0)
+		return true
 	default:
 		fmt.Println("Invalid choice. Please select a valid option.")
 	}
+	return false
 }
 
 func (app *TradeApp) tradeInputMode(reader *bufio.Reader) {
@@ -93,9 +163,15 @@ func (app *TradeApp) tradeInputMode(reader *bufio.Reader) {
 		} else {
 			fmt.Printf(Blue+"USD Balance - Total: %s | Holds: %s | Available: %s\n"+Reset, usdBalance.Amount, usdBalance.Holds, usdBalance.WithdrawableAmount)
 		}
+		if limit := app.dailyNotionalLimit(); limit.IsPositive() {
+			fmt.Printf(Blue+"Daily Notional - Used: %s | Limit: %s\n"+Reset, app.dailyNotional.Usage(time.Now()).StringFixed(2), limit.String())
+		}
+		if rate := app.feeRateBps(); rate.IsPositive() {
+			fmt.Printf(Blue+"Est. Fee Rate: %s bps\n"+Reset, rate.String())
+		}
 
-		fmt.Println("Enter trade. type 'h' for help. Type 'x' to quit.")
-		input, err := GetUserInput(reader)
+		fmt.Println("Enter trade. type 'h' for help. Type 'x' to quit. Press tab to complete products and keywords.")
+		input, err := app.readInput(reader, app.tradeInputCompleter())
 		if err != nil {
 			fmt.Println("Error reading input:", err)
 			continue
@@ -105,8 +181,34 @@ func (app *TradeApp) tradeInputMode(reader *bufio.Reader) {
 			break
 		}
 
+		if lower := strings.ToLower(input); lower == ArgReplay || lower == ArgAgain {
+			app.ReplayLastOrder(reader)
+			fmt.Println(LineSpacer)
+			continue
+		}
+
+		if strings.ToLower(input) == ArgCancelTwap {
+			fmt.Printf(Yellow+"Canceled %d TWAP schedule(s)\n"+Reset, app.CancelTwaps())
+			fmt.Println(LineSpacer)
+			continue
+		}
+
+		if strings.ToLower(input) == ArgRefreshCache {
+			app.balances.Invalidate()
+			app.RefreshPrices()
+			continue
+		}
+
+		if strings.ToLower(input) == ArgPanic {
+			if err := app.CancelAllOrders(); err != nil {
+				fmt.Printf(Red+"Error: %v\n"+Reset, err)
+			}
+			fmt.Println(LineSpacer)
+			continue
+		}
+
 		args := strings.Split(input, " ")
-		app.ProcessSimpleTradeInput(args)
+		app.ProcessSimpleTradeInput(args, reader)
 		if strings.ToLower(input) != "h" {
 			fmt.Println(LineSpacer)
 		}
@@ -121,38 +223,111 @@ func (app *TradeApp) orderManagerMode(reader *bufio.Reader) {
 		fmt.Printf("%d. Manage open orders\n", SelectOpenOrders)
 		fmt.Printf("%d. View recent closed orders\n", SelectClosedOrders)
 		fmt.Printf("%d. View portfolio balances\n", SelectBalances)
+		fmt.Printf("%d. View all balances\n", SelectAllBalances)
+		fmt.Printf("%d. Export orders to CSV\n", SelectExportOrders)
+		fmt.Printf("%d. Cancel all open orders\n", SelectCancelAllOrders)
+		fmt.Printf("%d. Look up an order by id\n", SelectLookupOrder)
+		fmt.Printf("%d. View positions and PnL\n", SelectPositions)
+		fmt.Printf("%d. View recent transfers and conversions\n", SelectTransactions)
+		fmt.Printf("%d. View wallets\n", SelectWallets)
 		fmt.Printf("Type '%s' to cancel\n", SelectExit)
 
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input, _ := app.readInput(reader, orderManagerCompleter())
 
 		if input == SelectExit {
 			return
 		}
 
 		choice, err := strconv.Atoi(input)
-		if err != nil || choice < SelectOpenOrders || choice > SelectBalances {
+		if err != nil || choice < SelectOpenOrders || choice > SelectWallets {
 			fmt.Println("Invalid choice. Please select again.")
 			continue
 		}
 
 		switch choice {
 		case SelectOpenOrders:
-			if err := app.GetOpenOrders(); err != nil {
+			fmt.Printf("Append '%s' to the filter to auto-refresh the open orders table.\n", ArgRefresh)
+			filter, err := promptOrderFilter(reader)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			if filter.Refresh {
+				err = app.WatchOpenOrders(filter, reader)
+			} else {
+				err = app.GetOpenOrders(filter, reader)
+			}
+			if err != nil {
 				fmt.Println("Error:", err)
 			}
 		case SelectClosedOrders:
-			if err := app.GetAllOrders(); err != nil {
+			filter, err := promptOrderFilter(reader)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			if err := app.GetAllOrders(filter, reader); err != nil {
 				fmt.Println("Error:", err)
 			}
 		case SelectBalances:
-			if err := app.ViewPortfolioBalances(); err != nil {
+			if err := app.ViewPortfolioBalances(reader); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectAllBalances:
+			if err := app.ViewAllBalances(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectExportOrders:
+			fmt.Printf("Enter a file name or press enter for '%s':\n", defaultExportFile)
+			path, err := GetUserInput(reader)
+			if err != nil {
+				fmt.Println("Error:", err)
+				continue
+			}
+			if path == "" {
+				path = defaultExportFile
+			}
+			if err := app.ExportOrders(path); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectCancelAllOrders:
+			if err := app.CancelAllOrders(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectLookupOrder:
+			fmt.Println("Enter an order id:")
+			orderId, err := GetUserInput(reader)
+			if err != nil || orderId == "" {
+				continue
+			}
+			if err := app.PrintOrder(orderId); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectPositions:
+			if err := app.ViewPositions(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectTransactions:
+			if err := app.ViewTransactions(reader); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case SelectWallets:
+			if err := app.ViewWallets(reader); err != nil {
 				fmt.Println("Error:", err)
 			}
 		}
 	}
 }
 
+func promptOrderFilter(reader *bufio.Reader) (orderFilter, error) {
+	fmt.Println("Enter a filter by product and/or side (e.g., 'eth-usd b') or press enter to show all:")
+	input, err := GetUserInput(reader)
+	if err != nil {
+		return orderFilter{}, err
+	}
+	return parseOrderFilter(input)
+}
+
 func loadConfig(fileName string) (*os.File, error) {
 	return os.Open(fileName)
 }
@@ -162,16 +337,319 @@ func loadAppSettings(cfg *os.File) (*quickfix.Settings, error) {
 }
 
 func loadCredentials(fileName string) (*config.Config, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
+	credentials := &config.Config{}
+
+	data, err := os.ReadFile(fileName)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, credentials); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", fileName, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		log.Printf("%s not found, reading credentials from the environment", fileName)
+		data = nil
+	default:
 		return nil, err
 	}
-	defer file.Close()
 
-	credentials := &config.Config{}
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&credentials)
-	return credentials, err
+	if err = migrateConfig(fileName, data, credentials); err != nil {
+		return nil, err
+	}
+	applyEnvCredentials(credentials)
+
+	if err = validateCredentials(credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func applyEnvCredentials(credentials *config.Config) {
+	envFields := []struct {
+		name  string
+		field *string
+	}{
+		{"CB_API_KEY", &credentials.ApiKey},
+		{"CB_API_SECRET", &credentials.ApiSecret},
+		{"CB_PASSPHRASE", &credentials.Passphrase},
+		{"CB_PORTFOLIO_ID", &credentials.PortfolioId},
+		{"CB_SVC_ACCOUNT_ID", &credentials.SvcAccountId},
+	}
+
+	for _, env := range envFields {
+		if value := os.Getenv(env.name); value != "" {
+			*env.field = value
+		}
+	}
+}
+
+func validateEndpoint(value string, schemes []string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%s has no host", value)
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must use one of: %s", value, strings.Join(schemes, ", "))
+}
+
+func missingCredentials(credentials *config.Config) []string {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"ApiKey (CB_API_KEY)", credentials.ApiKey},
+		{"ApiSecret (CB_API_SECRET)", credentials.ApiSecret},
+		{"Passphrase (CB_PASSPHRASE)", credentials.Passphrase},
+		{"PortfolioId (CB_PORTFOLIO_ID)", credentials.PortfolioId},
+		{"SvcAccountId (CB_SVC_ACCOUNT_ID)", credentials.SvcAccountId},
+	}
+
+	var missing []string
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	return missing
+}
+
+func validateCredentials(credentials *config.Config) error {
+	if missing := missingCredentials(credentials); len(missing) > 0 {
+		return fmt.Errorf("missing required credentials: %s", strings.Join(missing, ", "))
+	}
+
+	endpoints := []struct {
+		name    string
+		value   string
+		schemes []string
+	}{
+		{"RestBaseUrl", credentials.RestBaseUrl, []string{"https", "http"}},
+		{"WebSocketUri", credentials.WebSocketUri, []string{"wss", "ws"}},
+		{"PriceFeedUrl", credentials.PriceFeedUrl, []string{"https", "http"}},
+		{"ProxyUrl", credentials.ProxyUrl, []string{"http", "https"}},
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.value == "" {
+			continue
+		}
+		if err := validateEndpoint(endpoint.value, endpoint.schemes); err != nil {
+			return fmt.Errorf("invalid %s: %w", endpoint.name, err)
+		}
+	}
+
+	if len(credentials.SupportedProducts) == 0 {
+		credentials.SupportedProducts = defaultSupportedProducts
+	}
+	products := make([]string, 0, len(credentials.SupportedProducts))
+	for _, product := range credentials.SupportedProducts {
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid SupportedProducts: %w", err)
+		}
+		products = append(products, normalized)
+	}
+	credentials.SupportedProducts = products
+
+	watchlist := make([]string, 0, len(credentials.Watchlist))
+	for _, product := range credentials.Watchlist {
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid Watchlist: %w", err)
+		}
+		watchlist = append(watchlist, normalized)
+	}
+	credentials.Watchlist = watchlist
+
+	fiatAssets := make([]string, 0, len(credentials.FiatAssets))
+	for _, asset := range credentials.FiatAssets {
+		normalized, err := normalizeAsset(asset)
+		if err != nil {
+			return fmt.Errorf("invalid FiatAssets: %w", err)
+		}
+		fiatAssets = append(fiatAssets, normalized)
+	}
+	credentials.FiatAssets = fiatAssets
+
+	thresholds := make(map[string]float64, len(credentials.FfpThresholds))
+	for product, threshold := range credentials.FfpThresholds {
+		if threshold <= 0 || threshold >= 100 {
+			return fmt.Errorf("invalid fat finger threshold for %s: %v (must be a percentage between 0 and 100)", product, threshold)
+		}
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid FfpThresholds: %w", err)
+		}
+		thresholds[normalized] = threshold
+	}
+	credentials.FfpThresholds = thresholds
+
+	precisions := make(map[string]config.DisplayPrecision, len(credentials.BookPrecision))
+	for product, precision := range credentials.BookPrecision {
+		if precision.Price < 0 || precision.Quantity < 0 {
+			return fmt.Errorf("invalid book precision for %s: places must not be negative", product)
+		}
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid BookPrecision: %w", err)
+		}
+		precisions[normalized] = precision
+	}
+	credentials.BookPrecision = precisions
+
+	costBasis := make(map[string]decimal.Decimal, len(credentials.CostBasis))
+	for asset, basis := range credentials.CostBasis {
+		if !basis.IsPositive() {
+			return fmt.Errorf("invalid cost basis for %s: %s", asset, basis.String())
+		}
+		normalized, err := normalizeAsset(asset)
+		if err != nil {
+			return fmt.Errorf("invalid CostBasis: %w", err)
+		}
+		costBasis[normalized] = basis
+	}
+	credentials.CostBasis = costBasis
+
+	minOrderSizes := make(map[string]config.OrderMinimum, len(credentials.MinOrderSizes))
+	for product, minimum := range credentials.MinOrderSizes {
+		if minimum.BaseQuantity.IsNegative() || minimum.Notional.IsNegative() {
+			return fmt.Errorf("invalid min order size for %s: values must not be negative", product)
+		}
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid MinOrderSizes: %w", err)
+		}
+		minOrderSizes[normalized] = minimum
+	}
+	credentials.MinOrderSizes = minOrderSizes
+
+	maxOrderSizes := make(map[string]decimal.Decimal, len(credentials.ProductMaxOrderSizes))
+	for product, limit := range credentials.ProductMaxOrderSizes {
+		if !limit.IsPositive() {
+			return fmt.Errorf("invalid max order size for %s: %s", product, limit.String())
+		}
+		normalized, err := normalizeProduct(product)
+		if err != nil {
+			return fmt.Errorf("invalid ProductMaxOrderSizes: %w", err)
+		}
+		maxOrderSizes[normalized] = limit
+	}
+	credentials.ProductMaxOrderSizes = maxOrderSizes
+
+	if credentials.ConfirmThreshold != "" {
+		if threshold, err := decimal.NewFromString(credentials.ConfirmThreshold); err != nil || !threshold.IsPositive() {
+			return fmt.Errorf("invalid ConfirmThreshold: %s", credentials.ConfirmThreshold)
+		}
+	}
+
+	if credentials.FeeRateBps != "" {
+		if rate, err := decimal.NewFromString(credentials.FeeRateBps); err != nil || rate.IsNegative() {
+			return fmt.Errorf("invalid FeeRateBps: %s", credentials.FeeRateBps)
+		}
+	}
+
+	if credentials.MaxSlippagePct != "" {
+		if pct, err := decimal.NewFromString(credentials.MaxSlippagePct); err != nil || !pct.IsPositive() {
+			return fmt.Errorf("invalid MaxSlippagePct: %s", credentials.MaxSlippagePct)
+		}
+	}
+
+	if credentials.MaxDepthFraction != "" {
+		if fraction, err := decimal.NewFromString(credentials.MaxDepthFraction); err != nil || !fraction.IsPositive() || fraction.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("invalid MaxDepthFraction: %s", credentials.MaxDepthFraction)
+		}
+	}
+
+	if credentials.DailyNotionalLimit != "" {
+		if limit, err := decimal.NewFromString(credentials.DailyNotionalLimit); err != nil || !limit.IsPositive() {
+			return fmt.Errorf("invalid DailyNotionalLimit: %s", credentials.DailyNotionalLimit)
+		}
+	}
+
+	if credentials.RequestTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid RequestTimeoutSeconds: %d", credentials.RequestTimeoutSeconds)
+	}
+
+	if credentials.MaxRetries < 0 {
+		return fmt.Errorf("invalid MaxRetries: %d", credentials.MaxRetries)
+	}
+
+	if credentials.PriceFetchSeconds != 0 && credentials.PriceFetchSeconds < MinPriceFetchSeconds {
+		return fmt.Errorf("invalid PriceFetchSeconds: %v (must be at least %v)", credentials.PriceFetchSeconds, MinPriceFetchSeconds)
+	}
+
+	if credentials.RestRateLimit < 0 {
+		return fmt.Errorf("invalid RestRateLimit: %v", credentials.RestRateLimit)
+	}
+
+	if credentials.RestBurst < 0 {
+		return fmt.Errorf("invalid RestBurst: %d", credentials.RestBurst)
+	}
+
+	if credentials.OrdersPageSize < 0 {
+		return fmt.Errorf("invalid OrdersPageSize: %d", credentials.OrdersPageSize)
+	}
+
+	if credentials.WatchRefreshSeconds < 0 {
+		return fmt.Errorf("invalid WatchRefreshSeconds: %d", credentials.WatchRefreshSeconds)
+	}
+
+	if credentials.OrdersRefreshSeconds < 0 {
+		return fmt.Errorf("invalid OrdersRefreshSeconds: %d", credentials.OrdersRefreshSeconds)
+	}
+
+	if credentials.BalanceCacheSeconds < 0 {
+		return fmt.Errorf("invalid BalanceCacheSeconds: %d", credentials.BalanceCacheSeconds)
+	}
+
+	if credentials.MaxPriceAgeSeconds < 0 {
+		return fmt.Errorf("invalid MaxPriceAgeSeconds: %d", credentials.MaxPriceAgeSeconds)
+	}
+
+	if credentials.AckTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid AckTimeoutSeconds: %d", credentials.AckTimeoutSeconds)
+	}
+
+	if credentials.MaxClockDriftSeconds < 0 {
+		return fmt.Errorf("invalid MaxClockDriftSeconds: %d", credentials.MaxClockDriftSeconds)
+	}
+
+	if credentials.BookMaxDepth < 0 {
+		return fmt.Errorf("invalid BookMaxDepth: %d", credentials.BookMaxDepth)
+	}
+
+	if credentials.FixLivenessSeconds < 0 {
+		return fmt.Errorf("invalid FixLivenessSeconds: %d", credentials.FixLivenessSeconds)
+	}
+	if credentials.WsReadTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid WsReadTimeoutSeconds: %d", credentials.WsReadTimeoutSeconds)
+	}
+
+	if credentials.MetricsPort < 0 || credentials.MetricsPort > 65535 {
+		return fmt.Errorf("invalid MetricsPort: %d", credentials.MetricsPort)
+	}
+	return nil
+}
+
+func (app *TradeApp) Shutdown() {
+	app.cancel()
+	if app.stopPriceFetching != nil {
+		app.stopPriceFetching()
+	}
+	app.closeWebSocket()
+	if app.initiator != nil {
+		app.initiator.Stop()
+	}
+	app.audit.Close()
+}
+
+func (app *TradeApp) debugf(format string, v ...interface{}) {
+	app.logger.Log(LogLevelDebug, "debug", fmt.Sprintf(format, v...), nil)
 }
 
 func GetUserInput(reader *bufio.Reader) (string, error) {
@@ -183,7 +661,9 @@ func GetUserInput(reader *bufio.Reader) (string, error) {
 	return strings.TrimSpace(strings.TrimRight(input, "\n\r")), nil
 }
 
-func InitializeApp(args []string) (*quickfix.Settings, *config.Config) {
+// InitializeApp loads settings and credentials and returns any positional arguments after the
+// config path as a command to run instead of the interactive menu.
+func InitializeApp(args []string) (*quickfix.Settings, *config.Config, []string) {
 	cfg, err := loadConfig(args[1])
 	if err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
@@ -194,24 +674,123 @@ func InitializeApp(args []string) (*quickfix.Settings, *config.Config) {
 		log.Fatalf("Error parsing settings: %v", err)
 	}
 
-	credentials, err := loadCredentials(credsFile)
+	credentialsPath := credsFile
+	if path := os.Getenv(credsFileEnv); path != "" {
+		credentialsPath = path
+	}
+
+	jsonLogs, debug := false, false
+	outputFormat := ""
+	var command []string
+	for i := 2; i < len(args); i++ {
+		switch args[i] {
+		case ArgJsonLogs:
+			jsonLogs = true
+		case ArgDebug:
+			debug = true
+		case ArgCredsFile:
+			if i+1 >= len(args) {
+				log.Fatalf("%s flag should be followed by a file path", ArgCredsFile)
+			}
+			credentialsPath = args[i+1]
+			i++
+		case ArgFormat:
+			if i+1 >= len(args) {
+				log.Fatalf("%s flag should be followed by %s or %s", ArgFormat, FormatText, FormatJson)
+			}
+			outputFormat = args[i+1]
+			i++
+		default:
+			command = append(command, args[i])
+		}
+	}
+
+	credentials, err := loadCredentials(credentialsPath)
 	if err != nil {
 		log.Fatalf("Error loading credentials: %v", err)
 	}
+	credentialsFilePath = credentialsPath
+	if jsonLogs {
+		credentials.JsonLogs = true
+	}
+	if debug {
+		credentials.Debug = true
+	}
+	if outputFormat != "" {
+		credentials.OutputFormat = outputFormat
+	}
+	if err := validateOutputFormat(credentials.OutputFormat); err != nil {
+		log.Fatalf("Error loading credentials: %v", err)
+	}
 
-	return appSettings, credentials
+	return appSettings, credentials, command
+}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJson:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q, expected %s or %s", format, FormatText, FormatJson)
 }
 
 func CreateTradeApp(credentials *config.Config) *TradeApp {
+	maxOrderSize := parseMaxOrderSize(credentials.MaxOrderSize)
+	log.Printf("Max order size: %s", maxOrderSize.String())
+
+	var audit *auditLog
+	if credentials.AuditLogFile != "" {
+		var err error
+		audit, err = openAuditLog(credentials.AuditLogFile)
+		if err != nil {
+			log.Fatalf("Error opening audit log: %v", err)
+		}
+		log.Printf("Writing audit log to %s", credentials.AuditLogFile)
+	}
+
+	transport, err := newTransport(credentials.ProxyUrl, credentials.CaBundleFile)
+	if err != nil {
+		log.Fatalf("Error configuring HTTP client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &TradeApp{
+		ctx:           ctx,
+		cancel:        cancel,
 		MessageRouter: quickfix.NewMessageRouter(),
 		Config:        *credentials,
 		FirstPrint:    true,
-		MaxOrderSize:  MaxOrderSize,
+		MaxOrderSize:  maxOrderSize,
 		LogonChannel:  make(chan bool),
+		logonFailures: make(chan error, 1),
+		pendingOrders: make(map[string]pendingOrder),
+		twapSchedules: make(map[int]context.CancelFunc),
+		icebergs:      make(map[string]*icebergOrder),
+		logger:        newRedactingLogger(NewLogger(credentials.JsonLogs, credentials.Debug), credentials.ApiSecret, credentials.Passphrase, credentials.ApiKey),
+		restLimiter:   newRestLimiter(credentials),
+		priceLimiter:  rate.NewLimiter(priceFeedRateLimit, priceFeedRateLimit),
+		prices:        newPriceStore(),
+		balances:      newBalanceStore(),
+		audit:         audit,
+		httpClient:    &http.Client{Transport: transport},
+		wsDialer:      newWebSocketDialer(transport),
 	}
 }
 
+func parseMaxOrderSize(value string) decimal.Decimal {
+	if value == "" {
+		return MaxOrderSize
+	}
+
+	maxOrderSize, err := decimal.NewFromString(value)
+	if err != nil || !maxOrderSize.IsPositive() {
+		log.Printf(Yellow+"Warning: Invalid MaxOrderSize %q, falling back to %s"+Reset, value, MaxOrderSize.String())
+		return MaxOrderSize
+	}
+	return maxOrderSize
+}
+
 func StartServices(app *TradeApp, appSettings *quickfix.Settings) {
 	storeFactory := quickfix.NewFileStoreFactory(appSettings)
 	logFactory := quickfix.NewNullLogFactory()
@@ -221,10 +800,28 @@ func StartServices(app *TradeApp, appSettings *quickfix.Settings) {
 		log.Fatalf("Error creating initiator: %v", err)
 	}
 
+	StartMetricsServer(app)
+
+	if err := app.CheckRestCredentials(); err != nil {
+		log.Fatalf("Startup health check failed: %v", err)
+	}
+	log.Println("REST credentials verified")
+	app.CheckClockDrift()
+
+	app.initiator = initiator
 	go initiator.Start()
 
-	<-app.LogonChannel
+	select {
+	case <-app.LogonChannel:
+	case err := <-app.logonFailures:
+		initiator.Stop()
+		log.Fatalf("Startup health check failed: %v", err)
+	case <-time.After(logonTimeout):
+		initiator.Stop()
+		log.Fatalf("Startup health check failed: FIX logon not completed within %s. Check SvcAccountId in config.yaml, your API credentials and the FIX certificate.", logonTimeout)
+	}
 
-	products := supportedProducts
-	StartPriceFetchingTask(app, products, priceFetchGap)
+	app.stopPriceFetching = StartPriceFetchingTask(app.ctx, app, app.SupportedProducts, app.priceFetchInterval())
+	StartAckMonitor(app, ackCheckGap)
+	StartFixLivenessMonitor(app, livenessCheckGap)
 }
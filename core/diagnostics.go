@@ -0,0 +1,158 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+type diagnosticResult struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// RunDiagnostics checks credentials, clock, REST, websocket and FIX connectivity in turn and
+// reports each result, so a failed startup can be traced to a single cause.
+func (app *TradeApp) RunDiagnostics(appSettings *quickfix.Settings) error {
+	checks := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"Credentials present", app.diagnoseCredentials},
+		{"Clock drift", app.diagnoseClock},
+		{"REST reachability and auth", app.diagnoseRest},
+		{"Websocket connect and subscribe", app.diagnoseWebSocket},
+		{"FIX logon", func() (string, error) { return app.diagnoseFix(appSettings) }},
+	}
+
+	var results []diagnosticResult
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.run()
+		result := diagnosticResult{Check: check.name, Passed: err == nil, Detail: detail}
+		if err != nil {
+			result.Detail = err.Error()
+			failed++
+		}
+		results = append(results, result)
+		if !app.jsonOutput() {
+			printDiagnostic(result)
+		}
+	}
+
+	if app.jsonOutput() {
+		if err := writeJson(results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d diagnostic checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func printDiagnostic(result diagnosticResult) {
+	if result.Passed {
+		fmt.Printf(Green+"[PASS] %s"+Reset, result.Check)
+		if result.Detail != "" {
+			fmt.Printf(": %s", result.Detail)
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Printf(Red+"[FAIL] %s: %s\n"+Reset, result.Check, result.Detail)
+}
+
+func (app *TradeApp) diagnoseCredentials() (string, error) {
+	if missing := missingCredentials(&app.Config); len(missing) > 0 {
+		return "", fmt.Errorf("missing %s, set them in %s or the CB_* environment variables", strings.Join(missing, ", "), credentialsFilePath)
+	}
+	return "ApiKey, ApiSecret, Passphrase, PortfolioId and SvcAccountId are set", nil
+}
+
+func (app *TradeApp) diagnoseClock() (string, error) {
+	drift, err := app.measureClockDrift(app.ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the server time from %s, check network access: %w", app.restBaseUrl(), err)
+	}
+
+	tolerance := app.maxClockDrift()
+	if drift > tolerance || drift < -tolerance {
+		return "", fmt.Errorf("local clock is %s off from Coinbase (tolerance %s), sync your system clock with NTP", drift.Round(time.Millisecond), tolerance)
+	}
+	return fmt.Sprintf("%s off, within %s", drift.Round(time.Millisecond), tolerance), nil
+}
+
+func (app *TradeApp) diagnoseRest() (string, error) {
+	if err := app.CheckRestCredentials(); err != nil {
+		return "", err
+	}
+	return "portfolio " + app.PortfolioId + " is accessible", nil
+}
+
+func (app *TradeApp) diagnoseWebSocket() (string, error) {
+	if len(app.SupportedProducts) == 0 {
+		return "", errors.New("no SupportedProducts configured to subscribe to")
+	}
+	product := app.SupportedProducts[0]
+
+	c, err := app.dialL2([]string{product})
+	if err != nil {
+		return "", fmt.Errorf("unable to connect to %s, check network access and proxy settings: %w", app.webSocketUri(), err)
+	}
+	defer app.closeWebSocket()
+
+	_, message, err := c.ReadMessage()
+	if err != nil {
+		return "", fmt.Errorf("no response to the %s subscription: %w", product, err)
+	}
+	envelope, err := parseWsEnvelope(string(message))
+	if err != nil {
+		return "", fmt.Errorf("unexpected websocket response: %w", err)
+	}
+	if err := checkWsError(envelope, message); err != nil {
+		return "", err
+	}
+	return "subscribed to " + product, nil
+}
+
+func (app *TradeApp) diagnoseFix(appSettings *quickfix.Settings) (string, error) {
+	storeFactory := quickfix.NewFileStoreFactory(appSettings)
+	initiator, err := quickfix.NewInitiator(app, storeFactory, appSettings, quickfix.NewNullLogFactory())
+	if err != nil {
+		return "", fmt.Errorf("invalid FIX settings in config.yaml: %w", err)
+	}
+	if err := initiator.Start(); err != nil {
+		return "", fmt.Errorf("unable to start the FIX session: %w", err)
+	}
+	defer initiator.Stop()
+
+	select {
+	case <-app.LogonChannel:
+		return "session " + app.SessionId.String() + " logged on", nil
+	case err := <-app.logonFailures:
+		return "", err
+	case <-time.After(logonTimeout):
+		return "", fmt.Errorf("logon not completed within %s, check SvcAccountId in config.yaml, your API credentials and the FIX certificate", logonTimeout)
+	}
+}
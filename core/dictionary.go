@@ -34,6 +34,29 @@ var execTypeDescriptions = map[string]string{
 	"E": "ExecType_PENDING_REPLACE",
 }
 
+var businessRejectReasons = map[string]string{
+	"0": "Other",
+	"1": "Unknown ID",
+	"2": "Unknown Security",
+	"3": "Unsupported Message Type",
+	"4": "Application not available",
+	"5": "Conditionally required field missing",
+	"6": "Not authorized",
+	"7": "DeliverTo firm not available at this time",
+}
+
+var cancelRejectReasons = map[string]string{
+	"0": "Too late to cancel",
+	"1": "Unknown order",
+	"2": "Broker option",
+	"3": "Order already in Pending Cancel or Pending Replace status",
+}
+
+var cancelRejectResponses = map[string]string{
+	"1": "Order Cancel Request",
+	"2": "Order Cancel/Replace Request",
+}
+
 const (
 	BuyPriceMultiplier  = 1.05
 	SellPriceMultiplier = 0.95
@@ -61,41 +84,68 @@ const (
 )
 
 const (
-	FixMsgExecType     = "8"
-	FixMsgReject       = "3"
-	FixMsgLogon        = "A"
-	FixTagNewOrder     = "20=0"
-	FixTagPortfolioId  = 1
-	FixTagClOrdId      = 11
-	FixTagMsgSeqNum    = 34
-	FixTagMsgType      = 35
-	FixTagOrderId      = 37
-	FixTagOrderQty     = 38
-	FixTagOrdType      = 40
-	FixTagPrice        = 44
-	FixTagSendingTime  = 52
-	FixTagSide         = 54
-	FixTagSymbol       = 55
-	FixTagTargetCompId = 56
-	FixTagText         = 58
-	FixTagTimeInForce  = 59
-	FixTagRawDataLen   = 95
-	FixTagRawData      = 96
-	FixTagExecType     = 150
-	FixTagPassword     = 554
-	FixTagExecInst     = 847
-	FixTagAccessKey    = 9407
-	FixOrdTypeMarket   = "1"
-	FixOrdTypeLimit    = "2"
-	FixTimeInForceGTC  = "1"
-	FixTimeInForceIOC  = "3"
-	FixExecInstMarket  = "M"
-	FixExecInstLimit   = "L"
-	FixSideBuy         = "1"
-	FixSideSell        = "2"
-	FixExecNotReturned = "Not Returned"
-	FixExecCanceled    = "Canceled"
-	FixExecFill        = "Fill"
+	FixMsgExecType            = "8"
+	FixMsgReject              = "3"
+	FixMsgBusinessReject      = "j"
+	FixMsgOrderCancelReject   = "9"
+	FixMsgLogon               = "A"
+	FixMsgLogout              = "5"
+	FixMsgTestRequest         = "1"
+	FixMsgNewOrder            = "D"
+	FixMsgCancelReplace       = "G"
+	FixTagNewOrder            = "20=0"
+	FixTagPortfolioId         = 1
+	FixTagClOrdId             = 11
+	FixTagMsgSeqNum           = 34
+	FixTagMsgType             = 35
+	FixTagOrderId             = 37
+	FixTagOrderQty            = 38
+	FixTagOrigClOrdId         = 41
+	FixTagOrdType             = 40
+	FixTagPrice               = 44
+	FixTagRefSeqNum           = 45
+	FixTagCxlRejReason        = 102
+	FixTagRefMsgType          = 372
+	FixTagBusinessRejectRefId = 379
+	FixTagBusinessRejectCode  = 380
+	FixTagCxlRejResponseTo    = 434
+	FixTagSendingTime         = 52
+	FixTagSide                = 54
+	FixTagSymbol              = 55
+	FixTagTargetCompId        = 56
+	FixTagText                = 58
+	FixTagTimeInForce         = 59
+	FixTagRawDataLen          = 95
+	FixTagExpireTime          = 126
+	FixTagStopPx              = 99
+	FixTagTestReqId           = 112
+	FixTagRawData             = 96
+	FixTagExecType            = 150
+	FixTagCashOrderQty        = 152
+	FixTagPassword            = 554
+	FixTagExecInst            = 847
+	FixTagAccessKey           = 9407
+	FixOrdTypeMarket          = "1"
+	FixOrdTypeLimit           = "2"
+	FixOrdTypeStopLimit       = "4"
+	FixTimeInForceGTC         = "1"
+	FixTimeInForceIOC         = "3"
+	FixTimeInForceFOK         = "4"
+	FixTimeInForceGTD         = "6"
+	FixExecInstMarket         = "M"
+	FixExecInstLimit          = "L"
+	FixSideBuy                = "1"
+	FixSideSell               = "2"
+	FixExecNotReturned        = "Not Returned"
+	FixExecCanceled           = "Canceled"
+	FixExecFill               = "Fill"
+	FixExecTypeNew            = "0"
+	FixExecTypePartial        = "1"
+	FixExecTypeFill           = "2"
+	FixExecTypeCancel         = "4"
+	FixExecTypeReplaced       = "5"
+	FixExecTypeReject         = "8"
+	FixExecTypePendingReplace = "E"
 )
 
 const (
@@ -103,26 +153,74 @@ const (
 	SelectMarket    = "2"
 	SelectOrder     = "3"
 	SelectOco       = "4"
+	SelectWatchlist = "5"
+	SelectAlerts    = "6"
+	SelectWatch     = "w"
+	ArgAdd          = "add"
+	ArgRemove       = "rm"
 	SelectExit      = "x"
 	SelectExitWs    = "X"
+	SelectNextPage  = "n"
+	SelectModify    = "m"
+	SelectFills     = "f"
 	AppendCancel    = "-c"
+	ArgOco          = "-oco"
+	ArgLinkedOco    = "-link"
+	ArgStop         = "-stop"
+	ArgTrail        = "-trail"
+	ArgTwap         = "-twap"
+	ArgIceberg      = "-ice"
+	ArgGtt          = "-gtt"
+	ArgGtc          = "gtc"
+	ArgIoc          = "ioc"
+	ArgFok          = "fok"
+	ArgQuotePrefix  = "$"
+	ArgCancelTwap   = "cancel-twap"
 	ArgMarket       = "mkt"
 	ArgLimit        = "lim"
 	ArgBuy          = "b"
 	ArgSell         = "s"
+	ArgVwap         = "vwap"
+	ArgPanic        = "panic"
+	ArgReplay       = "."
+	ArgAgain        = "again"
+	ArgRefreshCache = "refresh"
+	ArgSnapshot     = "-snap"
+	ArgGroup        = "-g"
+	ArgRecord       = "-rec"
+	ArgFast         = "-fast"
+	ArgRefresh      = "-r"
+	ArgJsonLogs     = "--json"
+	ArgDebug        = "--debug"
+	ArgCredsFile    = "--creds"
+	ArgFormat       = "--format"
+	FormatText      = "text"
+	FormatJson      = "json"
 	TradeTypeMarket = "MARKET"
 	TradeTypeLimit  = "LIMIT"
 	TradeSideBuy    = "BUY"
 	TradeSideSell   = "SELL"
+	TimeInForceGTC  = "GTC"
+	TimeInForceIOC  = "IOC"
+	TimeInForceFOK  = "FOK"
 	LevelSideBid    = "bid"
 	LevelSideOffer  = "offer"
 	MinRequiredArgs = 4
+	MarketOrderArgs = 4
+	LimitOrderArgs  = 5
 )
 
 const (
 	SelectOpenOrders = iota + 1
 	SelectClosedOrders
 	SelectBalances
+	SelectAllBalances
+	SelectExportOrders
+	SelectCancelAllOrders
+	SelectLookupOrder
+	SelectPositions
+	SelectTransactions
+	SelectWallets
 )
 
 const (
@@ -130,4 +228,6 @@ const (
 	MarketData
 	OrderManager
 	OCOManager
+	WatchlistMenu
+	AlertsMenu
 )
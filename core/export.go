@@ -0,0 +1,104 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const defaultExportFile = "orders.csv"
+
+var orderCsvColumns = []struct {
+	Header string
+	Field  string
+}{
+	{"id", "id"},
+	{"product", "product_id"},
+	{"side", "side"},
+	{"type", "type"},
+	{"limit_price", "limit_price"},
+	{"base_quantity", "base_quantity"},
+	{"quote_value", "quote_value"},
+	{"status", "status"},
+	{"created_at", "created_at"},
+}
+
+func (app *TradeApp) ExportOrders(path string) error {
+	var orders []interface{}
+	cursor := ""
+	for {
+		page, nextCursor, err := app.fetchOrdersPage(cursor)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, page...)
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	if len(orders) == 0 {
+		fmt.Println("No orders found, nothing to export.")
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	header := make([]string, len(orderCsvColumns))
+	for i, column := range orderCsvColumns {
+		header[i] = column.Header
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		orderMap, ok := order.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		record := make([]string, len(orderCsvColumns))
+		for i, column := range orderCsvColumns {
+			record[i] = stringField(orderMap, column.Field)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	fmt.Printf(Blue+"Exported %d orders to %s\n"+Reset, len(orders), absPath)
+	return nil
+}
@@ -17,9 +17,7 @@ limitations under the License.
 package core
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/quickfixgo/quickfix"
@@ -28,6 +26,8 @@ import (
 	"strings"
 )
 
+var ErrNotConnected = errors.New("not connected")
+
 func (app *TradeApp) CreateHeader(portfolioId, messageType string) (*quickfix.Message, string) {
 	message := quickfix.NewMessage()
 
@@ -49,16 +49,58 @@ func (app *TradeApp) OnCreate(sessionId quickfix.SessionID) {
 func (app *TradeApp) OnLogon(sessionId quickfix.SessionID) {
 	fmt.Println(SuccessfulLogon)
 	app.SessionId = sessionId
+	app.setLoggedOn(true)
 	fmt.Println(Ascii)
-	app.LogonChannel <- true
+
+	select {
+	case app.LogonChannel <- true:
+	default:
+		fmt.Println(Green + "FIX session reconnected." + Reset)
+	}
 	return
 }
 
 func (app *TradeApp) OnLogout(sessionId quickfix.SessionID) {
-	fmt.Println("OnLogout")
+	app.setLoggedOn(false)
+	fmt.Println(Red + "OnLogout: FIX session disconnected, orders will not be sent until it reconnects." + Reset)
 	return
 }
 
+func (app *TradeApp) setLoggedOn(loggedOn bool) {
+	app.sessionMutex.Lock()
+	defer app.sessionMutex.Unlock()
+	app.loggedOn = loggedOn
+	if loggedOn {
+		app.hasLoggedOn = true
+	}
+}
+
+func (app *TradeApp) handleLogoutMessage(message *quickfix.Message) {
+	reason := FixExecNotReturned
+	if textField, err := message.Body.GetString(quickfix.Tag(FixTagText)); err == nil {
+		reason = textField
+	}
+
+	app.sessionMutex.Lock()
+	hasLoggedOn := app.hasLoggedOn
+	app.sessionMutex.Unlock()
+	if hasLoggedOn {
+		app.logger.Log(LogLevelWarn, "fix_logout", "Logout received, Reason: "+clockHint(reason), LogFields{"reason": reason})
+		return
+	}
+
+	select {
+	case app.logonFailures <- fmt.Errorf("FIX logon rejected, Reason: %s", clockHint(reason)):
+	default:
+	}
+}
+
+func (app *TradeApp) IsLoggedOn() bool {
+	app.sessionMutex.Lock()
+	defer app.sessionMutex.Unlock()
+	return app.loggedOn
+}
+
 func (app *TradeApp) onMessage(message *quickfix.Message, sessionId quickfix.SessionID) (reject quickfix.MessageRejectError) {
 	msgTypeField, err := message.Header.GetString(quickfix.Tag(FixTagMsgType))
 	if err != nil {
@@ -70,16 +112,74 @@ func (app *TradeApp) onMessage(message *quickfix.Message, sessionId quickfix.Ses
 			app.getExecType(message)
 		}
 	case FixMsgReject:
+		reason := FixExecNotReturned
 		if textField, err := message.Body.GetString(quickfix.Tag(FixTagText)); err == nil {
-			fmt.Println("Message Rejected, Reason:", textField)
-		} else {
-			fmt.Println("Message Rejected, Reason:", FixExecNotReturned)
+			reason = textField
 		}
+		promMetrics.reject("session")
+		app.logger.Log(LogLevelError, "fix_reject", "Message Rejected, Reason: "+clockHint(reason), LogFields{"reason": reason})
+	case FixMsgBusinessReject:
+		app.handleBusinessReject(message)
+	case FixMsgOrderCancelReject:
+		app.handleCancelReject(message)
 	}
 
 	return nil
 }
 
+func bodyField(message *quickfix.Message, tag int) string {
+	value, err := message.Body.GetString(quickfix.Tag(tag))
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func describeCode(code string, descriptions map[string]string) string {
+	if code == "" {
+		return FixExecNotReturned
+	}
+	if description, ok := descriptions[code]; ok {
+		return fmt.Sprintf("%s (%s)", description, code)
+	}
+	return fmt.Sprintf("Unknown (%s)", code)
+}
+
+func (app *TradeApp) handleBusinessReject(message *quickfix.Message) {
+	fields := LogFields{
+		"reason":       describeCode(bodyField(message, FixTagBusinessRejectCode), businessRejectReasons),
+		"text":         valueOrX(bodyField(message, FixTagText)),
+		"ref_seq_num":  valueOrX(bodyField(message, FixTagRefSeqNum)),
+		"ref_msg_type": valueOrX(bodyField(message, FixTagRefMsgType)),
+		"ref_id":       valueOrX(bodyField(message, FixTagBusinessRejectRefId)),
+	}
+
+	promMetrics.reject("business")
+	summary := fmt.Sprintf("Business Message Rejected, Reason: %s, Text: %s, RefMsgType: %s, RefSeqNum: %s, RefId: %s",
+		fields["reason"], fields["text"], fields["ref_msg_type"], fields["ref_seq_num"], fields["ref_id"])
+	app.logger.Log(LogLevelError, "fix_business_reject", summary, fields)
+	fields["fix"] = fixString(message)
+	app.audit.Record("business_reject", fields)
+}
+
+func (app *TradeApp) handleCancelReject(message *quickfix.Message) {
+	fields := LogFields{
+		"reason":         describeCode(bodyField(message, FixTagCxlRejReason), cancelRejectReasons),
+		"response_to":    describeCode(bodyField(message, FixTagCxlRejResponseTo), cancelRejectResponses),
+		"text":           valueOrX(bodyField(message, FixTagText)),
+		"order_id":       valueOrX(bodyField(message, FixTagOrderId)),
+		"cl_ord_id":      valueOrX(bodyField(message, FixTagClOrdId)),
+		"orig_cl_ord_id": valueOrX(bodyField(message, FixTagOrigClOrdId)),
+	}
+
+	promMetrics.reject("cancel")
+	summary := fmt.Sprintf("%s Rejected for OrderId: %s, Reason: %s, Text: %s",
+		fields["response_to"], fields["order_id"], fields["reason"], fields["text"])
+	app.logger.Log(LogLevelError, "fix_cancel_reject", summary, fields)
+	fields["fix"] = fixString(message)
+	app.audit.Record("cancel_reject", fields)
+}
+
 func (app *TradeApp) getExecType(message *quickfix.Message) {
 	app.stopOrdersMutex.Lock()
 	defer app.stopOrdersMutex.Unlock()
@@ -115,6 +215,7 @@ func (app *TradeApp) getExecType(message *quickfix.Message) {
 	if tempOrder, ok := tempStopOrders[clOrdIdField]; ok {
 
 		tempOrder.PlacedOrderId = orderIdField
+		tempOrder.ClOrdId = clOrdIdField
 		delete(tempStopOrders, clOrdIdField)
 
 		if !orderExistsInStopOrders(orderIdField) {
@@ -122,18 +223,55 @@ func (app *TradeApp) getExecType(message *quickfix.Message) {
 		}
 	}
 
-	if execTypeDescription == FixExecFill || execTypeDescription == FixExecCanceled {
+	if execTypeField == FixExecTypeFill || execTypeField == FixExecTypeCancel {
 		index := findOrderIndexById(orderIdField)
 		if index != -1 {
 			stopOrders = append(stopOrders[:index], stopOrders[index+1:]...)
 		}
 	}
 
-	if reason == FixExecNotReturned {
-		fmt.Printf(Green+"ExecType: %s (%s), OrderId: %s\n"+Reset, execTypeField, execTypeDescription, orderIdField)
-	} else {
-		fmt.Printf(Green+"ExecType: %s (%s), Reason: %s, OrderId: %s\n"+Reset, execTypeField, execTypeDescription, reason, orderIdField)
+	switch execTypeField {
+	case FixExecTypePendingReplace:
+		fmt.Printf(Yellow+"Modify pending for OrderId: %s\n"+Reset, orderIdField)
+	case FixExecTypeReplaced:
+		if origClOrdId, err := message.Body.GetString(quickfix.Tag(FixTagOrigClOrdId)); err == nil {
+			replaceOcoClOrdId(origClOrdId, clOrdIdField)
+		}
+		fmt.Printf(Green+"Order modified, OrderId: %s, ClOrdId: %s\n"+Reset, orderIdField, clOrdIdField)
+	}
+
+	switch execTypeField {
+	case FixExecTypeFill, FixExecTypePartial:
+		promMetrics.fill(execTypeDescription)
+		if symbol, err := message.Body.GetString(quickfix.Tag(FixTagSymbol)); err == nil {
+			app.balances.InvalidateProduct(symbol)
+		}
+	case FixExecTypeReject:
+		promMetrics.reject("order")
 	}
+
+	app.updateOcoPairs(clOrdIdField, orderIdField, execTypeField)
+	app.handleIcebergExec(clOrdIdField, execTypeField)
+	app.acknowledgeOrder(clOrdIdField, orderIdField, execTypeField)
+
+	summary := fmt.Sprintf("ExecType: %s (%s), OrderId: %s", execTypeField, execTypeDescription, orderIdField)
+	if reason != FixExecNotReturned {
+		summary = fmt.Sprintf("ExecType: %s (%s), Reason: %s, OrderId: %s", execTypeField, execTypeDescription, reason, orderIdField)
+	}
+	app.logger.Log(LogLevelInfo, "exec_report", summary, LogFields{
+		"exec_type":   execTypeField,
+		"description": execTypeDescription,
+		"order_id":    orderIdField,
+		"cl_ord_id":   clOrdIdField,
+		"reason":      reason,
+	})
+	app.audit.Record("exec_report", LogFields{
+		"exec_type": execTypeField,
+		"order_id":  orderIdField,
+		"cl_ord_id": clOrdIdField,
+		"reason":    reason,
+		"fix":       fixString(message),
+	})
 }
 
 func (app *TradeApp) ToAdmin(message *quickfix.Message, sessionId quickfix.SessionID) {
@@ -153,28 +291,36 @@ func (app *TradeApp) ToAdmin(message *quickfix.Message, sessionId quickfix.Sessi
 		message.Header.SetField(quickfix.Tag(FixTagRawDataLen), quickfix.FIXInt(len(rawData)))
 		message.Header.SetField(quickfix.Tag(FixTagAccessKey), quickfix.FIXString(app.ApiKey))
 	}
-	fmt.Println(Green+"(Admin) S >> "+Reset, message)
+	app.logger.Log(LogLevelInfo, "fix_admin_out", "(Admin) S >> "+fixString(message), LogFields{"msg_type": msgTypeField, "fix": fixString(message)})
 }
 
 func (app *TradeApp) ToApp(message *quickfix.Message, sessionId quickfix.SessionID) (err error) {
+	app.logger.Log(LogLevelDebug, "fix_app_out", "(App) S >> "+fixString(message), LogFields{"fix": fixString(message)})
 	return
 }
 
 func (app *TradeApp) FromAdmin(message *quickfix.Message, sessionId quickfix.SessionID) (reject quickfix.MessageRejectError) {
-	fmt.Println(Green+"(Admin) R << "+Reset, message)
+	app.recordFixMessage()
+	app.logger.Log(LogLevelInfo, "fix_admin_in", "(Admin) R << "+fixString(message), LogFields{"fix": fixString(message)})
+	if msgType, err := message.Header.GetString(quickfix.Tag(FixTagMsgType)); err == nil && msgType == FixMsgLogout {
+		app.handleLogoutMessage(message)
+	}
 	app.onMessage(message, sessionId)
 	return nil
 }
 
 func (app *TradeApp) FromApp(message *quickfix.Message, sessionId quickfix.SessionID) (reject quickfix.MessageRejectError) {
+	app.recordFixMessage()
+	app.logger.Log(LogLevelDebug, "fix_app_in", "(App) R << "+fixString(message), LogFields{"fix": fixString(message)})
 	app.onMessage(message, sessionId)
 	return nil
 }
 
-func (app TradeApp) sign(t, msgType, seqNum, targetCompId string) string {
-	message := []byte(t + msgType + seqNum + app.ApiKey + targetCompId + app.Passphrase)
-	hmac256 := hmac.New(sha256.New, []byte(app.ApiSecret))
-	hmac256.Write(message)
-	signature := base64.StdEncoding.EncodeToString(hmac256.Sum(nil))
-	return signature
+func (app *TradeApp) sign(t, msgType, seqNum, targetCompId string) string {
+	return computeHMAC256(fixSignatureMessage(t, msgType, seqNum, app.ApiKey, targetCompId, app.Passphrase), app.ApiSecret)
+}
+
+// fixSignatureMessage builds the logon prehash: SendingTime + MsgType + MsgSeqNum + api key + TargetCompID + passphrase.
+func fixSignatureMessage(sendingTime, msgType, seqNum, apiKey, targetCompId, passphrase string) string {
+	return sendingTime + msgType + seqNum + apiKey + targetCompId + passphrase
 }
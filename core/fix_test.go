@@ -0,0 +1,40 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import "testing"
+
+func TestFixSignatureMessage(t *testing.T) {
+	got := fixSignatureMessage("20230101-00:00:00.000", "A", "1", "test-key", "COIN", "test-passphrase")
+	want := "20230101-00:00:00.000A1test-keyCOINtest-passphrase"
+	if got != want {
+		t.Errorf("fixSignatureMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestFixSign(t *testing.T) {
+	app := &TradeApp{}
+	app.ApiKey = "test-key"
+	app.ApiSecret = "test-secret"
+	app.Passphrase = "test-passphrase"
+
+	got := app.sign("20230101-00:00:00.000", "A", "1", "COIN")
+	want := "4hof4sL8Z53pu4ff1deWVYda54RIOdgnK4il+gwCFOo="
+	if got != want {
+		t.Errorf("sign() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+type icebergOrder struct {
+	Id         int
+	Params     parsedTradeParams
+	LimitPrice string
+	DisplayQty decimal.Decimal
+	TotalQty   decimal.Decimal
+	SentQty    decimal.Decimal
+	FilledQty  decimal.Decimal
+	ChildQty   decimal.Decimal
+}
+
+func (app *TradeApp) SubmitIceberg(params parsedTradeParams, limitPrice string, displayQty decimal.Decimal) (int, error) {
+	if params.OrderType != TradeTypeLimit {
+		return 0, fmt.Errorf("iceberg orders must be limit (lim) orders")
+	}
+
+	totalQty, err := decimal.NewFromString(params.BaseQuantity)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", params.BaseQuantity)
+	}
+	if !displayQty.IsPositive() || displayQty.GreaterThanOrEqual(totalQty) {
+		return 0, fmt.Errorf("display size must be positive and smaller than the total size")
+	}
+
+	app.icebergMutex.Lock()
+	defer app.icebergMutex.Unlock()
+
+	app.nextIcebergId++
+	order := &icebergOrder{
+		Id:         app.nextIcebergId,
+		Params:     params,
+		LimitPrice: limitPrice,
+		DisplayQty: displayQty,
+		TotalQty:   totalQty,
+		SentQty:    decimal.Zero,
+		FilledQty:  decimal.Zero,
+	}
+	if err := app.sendIcebergSlice(order); err != nil {
+		return 0, err
+	}
+	return order.Id, nil
+}
+
+// sendIcebergSlice must be called with icebergMutex held.
+func (app *TradeApp) sendIcebergSlice(order *icebergOrder) error {
+	qty := decimal.Min(order.DisplayQty, order.TotalQty.Sub(order.SentQty))
+	child := order.Params
+	child.BaseQuantity = qty.String()
+
+	clOrdId, err := app.ConstructTrade(child, order.LimitPrice, app.SessionId)
+	if err != nil {
+		return err
+	}
+
+	order.SentQty = order.SentQty.Add(qty)
+	order.ChildQty = qty
+	app.icebergs[clOrdId] = order
+	return nil
+}
+
+func (app *TradeApp) handleIcebergExec(clOrdId, execType string) {
+	switch execType {
+	case FixExecTypeFill, FixExecTypeCancel, FixExecTypeReject:
+	default:
+		return
+	}
+
+	go func() {
+		app.icebergMutex.Lock()
+		defer app.icebergMutex.Unlock()
+
+		order, ok := app.icebergs[clOrdId]
+		if !ok {
+			return
+		}
+		delete(app.icebergs, clOrdId)
+
+		if execType != FixExecTypeFill {
+			fmt.Printf(Yellow+"Iceberg #%d stopped: child order %s was canceled or rejected, %s of %s filled\n"+Reset, order.Id, clOrdId, order.FilledQty.String(), order.TotalQty.String())
+			return
+		}
+
+		order.FilledQty = order.FilledQty.Add(order.ChildQty)
+		if order.SentQty.GreaterThanOrEqual(order.TotalQty) {
+			fmt.Printf(Green+"Iceberg #%d complete: %s %s filled\n"+Reset, order.Id, order.FilledQty.String(), order.Params.Product)
+			return
+		}
+
+		fmt.Printf(Blue+"Iceberg #%d: %s of %s filled, sending next slice\n"+Reset, order.Id, order.FilledQty.String(), order.TotalQty.String())
+		if err := app.sendIcebergSlice(order); err != nil {
+			app.logOrderFailure(order.Params, fmt.Errorf("iceberg #%d stopped: %w", order.Id, err))
+		}
+	}()
+}
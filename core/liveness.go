@@ -0,0 +1,116 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quickfixgo/quickfix"
+)
+
+const DefaultFixLivenessWindow = 60 * time.Second
+
+func (app *TradeApp) fixLivenessWindow() time.Duration {
+	if app.FixLivenessSeconds <= 0 {
+		return DefaultFixLivenessWindow
+	}
+	return time.Duration(app.FixLivenessSeconds) * time.Second
+}
+
+func (app *TradeApp) recordFixMessage() {
+	app.sessionMutex.Lock()
+	defer app.sessionMutex.Unlock()
+	app.lastFixMessage = time.Now()
+	app.testRequestSentAt = time.Time{}
+	app.livenessResetSent = false
+}
+
+// SinceLastFixMessage reports how long the FIX session has been silent, or false before the first message.
+func (app *TradeApp) SinceLastFixMessage() (time.Duration, bool) {
+	app.sessionMutex.Lock()
+	defer app.sessionMutex.Unlock()
+	if app.lastFixMessage.IsZero() {
+		return 0, false
+	}
+	return time.Since(app.lastFixMessage), true
+}
+
+// checkFixLiveness probes a silent session with a TestRequest, then warns and optionally
+// sends a Logout to force a reconnect if the probe also goes unanswered.
+func (app *TradeApp) checkFixLiveness(now time.Time) {
+	if !app.IsLoggedOn() {
+		return
+	}
+	window := app.fixLivenessWindow()
+
+	app.sessionMutex.Lock()
+	silence := now.Sub(app.lastFixMessage)
+	probeSent := !app.testRequestSentAt.IsZero()
+	probeExpired := probeSent && now.Sub(app.testRequestSentAt) >= window
+	resetSent := app.livenessResetSent
+	if silence >= window && !probeSent {
+		app.testRequestSentAt = now
+	}
+	app.sessionMutex.Unlock()
+
+	switch {
+	case silence >= window && !probeSent:
+		app.debugf("No FIX message for %s, sending TestRequest", silence.Round(time.Second))
+		if err := app.sendAdminMessage(FixMsgTestRequest, quickfix.Tag(FixTagTestReqId), uuid.New().String()); err != nil {
+			app.debugf("Failed to send TestRequest: %v", err)
+		}
+	case probeExpired && !resetSent:
+		app.logger.Log(LogLevelWarn, "fix_stale", fmt.Sprintf("Warning: No FIX message received for %s, the session may be dead.", silence.Round(time.Second)), LogFields{
+			"silence_seconds": int(silence.Seconds()),
+		})
+		if !app.FixResetOnStale {
+			return
+		}
+		app.sessionMutex.Lock()
+		app.livenessResetSent = true
+		app.sessionMutex.Unlock()
+		app.logger.Log(LogLevelWarn, "fix_reset", "Sending Logout to force the FIX session to reconnect.", nil)
+		if err := app.sendAdminMessage(FixMsgLogout, quickfix.Tag(FixTagText), "liveness timeout"); err != nil {
+			app.logger.Log(LogLevelError, "fix_reset_failed", fmt.Sprintf("Failed to send Logout: %v", err), LogFields{"error": err})
+		}
+	}
+}
+
+func (app *TradeApp) sendAdminMessage(msgType string, tag quickfix.Tag, value string) error {
+	msg := quickfix.NewMessage()
+	msg.Header.SetString(quickfix.Tag(FixTagMsgType), msgType)
+	msg.Body.SetString(tag, value)
+	return quickfix.SendToTarget(msg, app.SessionId)
+}
+
+func StartFixLivenessMonitor(app *TradeApp, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-app.ctx.Done():
+				return
+			case now := <-ticker.C:
+				app.checkFixLiveness(now)
+			}
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+type LogFields map[string]interface{}
+
+type Logger interface {
+	Log(level, event, message string, fields LogFields)
+}
+
+func NewLogger(jsonOutput, debug bool) Logger {
+	if jsonOutput {
+		return &jsonLogger{out: os.Stdout, debug: debug}
+	}
+	return &prettyLogger{debug: debug}
+}
+
+type prettyLogger struct {
+	debug bool
+}
+
+func (l *prettyLogger) Log(level, event, message string, fields LogFields) {
+	switch level {
+	case LogLevelDebug:
+		if l.debug {
+			log.Printf("[debug] %s", message)
+		}
+	case LogLevelWarn:
+		fmt.Println(Yellow + message + Reset)
+	case LogLevelError:
+		fmt.Println(Red + message + Reset)
+	default:
+		fmt.Println(Green + message + Reset)
+	}
+}
+
+type jsonLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	debug bool
+}
+
+func (l *jsonLogger) Log(level, event, message string, fields LogFields) {
+	if level == LogLevelDebug && !l.debug {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(fields)+4)
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["event"] = event
+	entry["msg"] = message
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := json.NewEncoder(l.out).Encode(entry); err != nil {
+		log.Printf("Failed to write log entry: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	DefaultMetricsPort = 9090
+	metricsNamespace   = "trader_shell"
+)
+
+type tradeMetrics struct {
+	ordersSubmitted     prometheus.Counter
+	cancels             prometheus.Counter
+	fills               *prometheus.CounterVec
+	rejects             *prometheus.CounterVec
+	websocketReconnects prometheus.Counter
+	restLatency         *prometheus.HistogramVec
+}
+
+var promMetrics *tradeMetrics
+
+func newTradeMetrics(registerer prometheus.Registerer) *tradeMetrics {
+	m := &tradeMetrics{
+		ordersSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "orders_submitted_total",
+			Help:      "Orders sent over FIX.",
+		}),
+		cancels: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "order_cancels_total",
+			Help:      "Cancel requests accepted by the REST API.",
+		}),
+		fills: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "fills_total",
+			Help:      "Fill execution reports received, by exec type.",
+		}, []string{"exec_type"}),
+		rejects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rejects_total",
+			Help:      "Order, cancel, business and session rejects received over FIX.",
+		}, []string{"source"}),
+		websocketReconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "websocket_reconnects_total",
+			Help:      "Market data websocket reconnect attempts.",
+		}),
+		restLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "rest_request_duration_seconds",
+			Help:      "REST request latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+
+	registerer.MustRegister(m.ordersSubmitted, m.cancels, m.fills, m.rejects, m.websocketReconnects, m.restLatency)
+	return m
+}
+
+func StartMetricsServer(app *TradeApp) {
+	if !app.MetricsEnabled {
+		return
+	}
+
+	promMetrics = newTradeMetrics(prometheus.DefaultRegisterer)
+
+	port := app.MetricsPort
+	if port <= 0 {
+		port = DefaultMetricsPort
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	addr := ":" + strconv.Itoa(port)
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf(Red+"Metrics server stopped: %v"+Reset, err)
+		}
+	}()
+	log.Printf("Serving metrics on %s/metrics", addr)
+}
+
+func (m *tradeMetrics) orderSubmitted() {
+	if m != nil {
+		m.ordersSubmitted.Inc()
+	}
+}
+
+func (m *tradeMetrics) orderCanceled() {
+	if m != nil {
+		m.cancels.Inc()
+	}
+}
+
+func (m *tradeMetrics) fill(execType string) {
+	if m != nil {
+		m.fills.WithLabelValues(execType).Inc()
+	}
+}
+
+func (m *tradeMetrics) reject(source string) {
+	if m != nil {
+		m.rejects.WithLabelValues(source).Inc()
+	}
+}
+
+func (m *tradeMetrics) websocketReconnect() {
+	if m != nil {
+		m.websocketReconnects.Inc()
+	}
+}
+
+func (m *tradeMetrics) observeRestLatency(method string, statusCode int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	code := "error"
+	if statusCode > 0 {
+		code = fmt.Sprint(statusCode)
+	}
+	m.restLatency.WithLabelValues(method, code).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/trader-shell-go/config"
+)
+
+const CurrentConfigVersion = 1
+
+var configDefaults = []struct {
+	field string
+	apply func(*config.Config)
+}{
+	{"RestBaseUrl", func(c *config.Config) { c.RestBaseUrl = BaseURL }},
+	{"WebSocketUri", func(c *config.Config) { c.WebSocketUri = Uri }},
+	{"PriceFeedUrl", func(c *config.Config) { c.PriceFeedUrl = PriceFeedURL }},
+	{"MaxSlippagePct", func(c *config.Config) { c.MaxSlippagePct = DefaultMaxSlippagePct }},
+	{"MaxDepthFraction", func(c *config.Config) { c.MaxDepthFraction = DefaultMaxDepthFraction }},
+	{"BookMaxDepth", func(c *config.Config) { c.BookMaxDepth = DefaultBookMaxDepth }},
+	{"RequestTimeoutSeconds", func(c *config.Config) { c.RequestTimeoutSeconds = int(DefaultRequestTimeout / time.Second) }},
+	{"MaxRetries", func(c *config.Config) { c.MaxRetries = DefaultMaxRetries }},
+	{"RestRateLimit", func(c *config.Config) { c.RestRateLimit = DefaultRestRateLimit }},
+	{"RestBurst", func(c *config.Config) { c.RestBurst = DefaultRestBurst }},
+	{"OrdersPageSize", func(c *config.Config) { c.OrdersPageSize = DefaultOrdersPageSize }},
+	{"MetricsPort", func(c *config.Config) { c.MetricsPort = DefaultMetricsPort }},
+}
+
+func configFieldNames() map[string]string {
+	names := make(map[string]string)
+	configType := reflect.TypeOf(config.Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		name := configType.Field(i).Name
+		names[strings.ToLower(name)] = name
+	}
+	return names
+}
+
+func migrateConfig(fileName string, data []byte, credentials *config.Config) error {
+	raw := make(map[string]json.RawMessage)
+	if data != nil {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", fileName, err)
+		}
+	}
+
+	present := make(map[string]bool)
+	known := configFieldNames()
+	for key := range raw {
+		name, ok := known[strings.ToLower(key)]
+		if !ok {
+			log.Printf(Yellow+"Warning: %s has unknown field %q, which is ignored; it may be misspelled or no longer supported"+Reset, fileName, key)
+			continue
+		}
+		present[name] = true
+	}
+
+	if credentials.Version > CurrentConfigVersion {
+		log.Printf(Yellow+"Warning: %s is config version %d, newer than the supported version %d"+Reset, fileName, credentials.Version, CurrentConfigVersion)
+		return nil
+	}
+
+	for _, def := range configDefaults {
+		if !present[def.field] {
+			def.apply(credentials)
+		}
+	}
+
+	if data != nil && credentials.Version < CurrentConfigVersion {
+		log.Printf("%s is config version %d, defaults were applied for missing fields; set \"Version\": %d to silence this notice", fileName, credentials.Version, CurrentConfigVersion)
+	}
+	credentials.Version = CurrentConfigVersion
+	return nil
+}
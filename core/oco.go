@@ -19,20 +19,19 @@ package core
 import (
 	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 )
 
-func (app *TradeApp) displayStopOrders() {
-	reader := bufio.NewReader(os.Stdin)
+func (app *TradeApp) displayStopOrders(reader *bufio.Reader) {
 	for {
-		if len(stopOrders) == 0 {
+		orders := app.snapshotStopOrders()
+		if len(orders) == 0 {
 			fmt.Println("No stop orders found!")
 			return
 		}
 
-		app.printStopOrders()
+		app.printStopOrders(orders)
 
 		fmt.Print("Select a stop order by number with '-c' to cancel, or type 'x' to return to previous menu: ")
 
@@ -55,23 +54,67 @@ func (app *TradeApp) displayStopOrders() {
 		}
 
 		choice, err := strconv.Atoi(input)
-		if err != nil || choice <= 0 || choice > len(stopOrders) {
+		if err != nil || choice <= 0 || choice > len(orders) {
 			fmt.Println("Invalid choice. Please select again.")
 			continue
 		}
 
 		if autoCancel {
-			stopOrders = append(stopOrders[:choice-1], stopOrders[choice:]...)
-			fmt.Printf("Removed stop order #%d\n", choice)
+			if app.removePendingStop(orders[choice-1]) {
+				fmt.Printf("Removed stop order #%d\n", choice)
+			} else {
+				fmt.Printf("Stop order #%d was already triggered or removed\n", choice)
+			}
 		}
 	}
 }
 
-func (app *TradeApp) printStopOrders() {
-	fmt.Println(Blue + "No. | Product | Side | Amount | Stop Price | Linked Order Id" + Reset)
+// snapshotStopOrders copies the pending stops under the lock so they can be printed and
+// selected from while the price feed keeps triggering them.
+func (app *TradeApp) snapshotStopOrders() []stopOrder {
+	app.stopOrdersMutex.Lock()
+	defer app.stopOrdersMutex.Unlock()
+	return append([]stopOrder(nil), stopOrders...)
+}
+
+// removePendingStop drops the pending stop matching order, reporting false if it has since
+// been triggered or removed.
+func (app *TradeApp) removePendingStop(order stopOrder) bool {
+	app.stopOrdersMutex.Lock()
+	defer app.stopOrdersMutex.Unlock()
+	for i, pending := range stopOrders {
+		if sameStopOrder(pending, order) {
+			stopOrders = append(stopOrders[:i], stopOrders[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// sameStopOrder ignores StopPrice and HighWaterMark, which a trailing stop moves after the snapshot.
+func sameStopOrder(a, b stopOrder) bool {
+	return a.Product == b.Product && a.Side == b.Side && a.Amount == b.Amount &&
+		a.BaseQuantity == b.BaseQuantity && a.PlacedOrderId == b.PlacedOrderId &&
+		a.LimitPrice.Equal(b.LimitPrice) && a.TrailOffset.Equal(b.TrailOffset) && a.TrailPercent == b.TrailPercent
+}
+
+func (app *TradeApp) printStopOrders(orders []stopOrder) {
+	fmt.Println(Blue + "No. | Product | Side | Amount | Stop Price | Limit Price | Linked Order Id" + Reset)
 	fmt.Println(LineSpacer)
-	for i, order := range stopOrders {
-		fmt.Printf(Blue+"%d. %s | %s | %f | %s | %s\n"+Reset, i+1, order.Product, order.Side, order.Amount, order.StopPrice.String(), order.PlacedOrderId)
+	for i, order := range orders {
+		limitPrice := "MKT"
+		if !order.LimitPrice.IsZero() {
+			limitPrice = order.LimitPrice.String()
+		}
+		stopPrice := order.StopPrice.String()
+		if order.TrailOffset.IsPositive() {
+			trail := order.TrailOffset.String()
+			if order.TrailPercent {
+				trail += "%"
+			}
+			stopPrice = fmt.Sprintf("%s (trail %s)", formatIncrement(order.StopPrice.String(), app.productIncrements(order.Product).Quote), trail)
+		}
+		fmt.Printf(Blue+"%d. %s | %s | %f | %s | %s | %s\n"+Reset, i+1, order.Product, order.Side, order.Amount, stopPrice, limitPrice, valueOrX(order.PlacedOrderId))
 	}
 }
 
@@ -92,3 +135,111 @@ func findOrderIndexById(orderId string) int {
 	}
 	return -1
 }
+
+type ocoPair struct {
+	Product           string
+	Side              string
+	BaseQuantity      string
+	TakeProfitPrice   string
+	StopPrice         string
+	TakeProfitClOrdId string
+	StopClOrdId       string
+	TakeProfitOrderId string
+	StopOrderId       string
+	Triggered         bool
+}
+
+var ocoPairs []*ocoPair
+
+// SubmitOco sends a resting take-profit limit and a venue-side stop-limit at stopPrice, and links
+// them so a fill on either leg cancels the other. The lock is held across both sends, so exec
+// reports for either leg wait in getExecType until the pair is registered.
+func (app *TradeApp) SubmitOco(params parsedTradeParams, takeProfitPrice, stopPrice string) error {
+	pair := &ocoPair{
+		Product:         params.Product,
+		Side:            params.Side,
+		BaseQuantity:    params.BaseQuantity,
+		TakeProfitPrice: takeProfitPrice,
+		StopPrice:       stopPrice,
+	}
+	stopParams := params
+	stopParams.StopPrice = stopPrice
+
+	app.stopOrdersMutex.Lock()
+	defer app.stopOrdersMutex.Unlock()
+
+	var err error
+	if pair.TakeProfitClOrdId, err = app.ConstructTrade(params, takeProfitPrice, app.SessionId); err != nil {
+		return fmt.Errorf("take profit leg not sent: %w", err)
+	}
+	ocoPairs = append(ocoPairs, pair)
+
+	if pair.StopClOrdId, err = app.ConstructTrade(stopParams, stopPrice, app.SessionId); err != nil {
+		// Marking the pair triggered makes updateOcoPairs cancel the take profit as soon as its
+		// order id is known from the first exec report.
+		pair.Triggered = true
+		return fmt.Errorf("stop leg not sent, canceling take profit %s: %w", pair.TakeProfitClOrdId, err)
+	}
+
+	fmt.Printf(Blue+"Submitted linked OCO for %s: take profit @ %s, stop @ %s\n"+Reset, pair.Product, takeProfitPrice, stopPrice)
+	return nil
+}
+
+// updateOcoPairs must be called with stopOrdersMutex held.
+func (app *TradeApp) updateOcoPairs(clOrdId, orderId, execType string) {
+	for i, pair := range ocoPairs {
+		var siblingOrderId *string
+		var siblingClOrdId string
+		switch clOrdId {
+		case pair.TakeProfitClOrdId:
+			pair.TakeProfitOrderId = orderId
+			siblingOrderId, siblingClOrdId = &pair.StopOrderId, pair.StopClOrdId
+		case pair.StopClOrdId:
+			pair.StopOrderId = orderId
+			siblingOrderId, siblingClOrdId = &pair.TakeProfitOrderId, pair.TakeProfitClOrdId
+		default:
+			continue
+		}
+
+		switch execType {
+		case FixExecTypeFill, FixExecTypePartial:
+			if !pair.Triggered {
+				pair.Triggered = true
+				if *siblingOrderId != "" {
+					go app.cancelOcoSibling(*siblingOrderId, siblingClOrdId)
+				}
+			}
+		case FixExecTypeNew:
+			if pair.Triggered {
+				go app.cancelOcoSibling(orderId, clOrdId)
+			}
+		}
+
+		// A pair whose stop leg was never sent has no stop order id to wait for.
+		stopDone := pair.StopOrderId != "" || pair.StopClOrdId == ""
+		if pair.Triggered && pair.TakeProfitOrderId != "" && stopDone {
+			ocoPairs = append(ocoPairs[:i], ocoPairs[i+1:]...)
+		}
+		return
+	}
+}
+
+func (app *TradeApp) cancelOcoSibling(orderId, clOrdId string) {
+	if err := app.CancelOrder(orderId, clOrdId); err != nil {
+		app.logCancelFailure(orderId, err)
+		return
+	}
+	app.logger.Log(LogLevelInfo, "oco_sibling_canceled", fmt.Sprintf("Canceled linked OCO order %s", orderId), LogFields{"order_id": orderId})
+}
+
+// replaceOcoClOrdId must be called with stopOrdersMutex held.
+func replaceOcoClOrdId(origClOrdId, clOrdId string) {
+	for _, pair := range ocoPairs {
+		switch origClOrdId {
+		case pair.TakeProfitClOrdId:
+			pair.TakeProfitClOrdId = clOrdId
+		case pair.StopClOrdId:
+			pair.StopClOrdId = clOrdId
+		}
+	}
+}
@@ -18,11 +18,15 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"sort"
 	"strconv"
+	"time"
+
+	"github.com/coinbase-samples/trader-shell-go/config"
+	"github.com/shopspring/decimal"
 )
 
 type LevelJson struct {
@@ -32,17 +36,39 @@ type LevelJson struct {
 }
 
 type Level struct {
-	Side string  `json:"side"`
-	Px   float64 `json:"px"`
-	Qty  float64 `json:"qty"`
+	Side string          `json:"side"`
+	Px   decimal.Decimal `json:"px"`
+	Qty  decimal.Decimal `json:"qty"`
+}
+
+type vwapQuery struct {
+	Side string
+	Size float64
 }
 
+const DefaultBookMaxDepth = 100
+
+var ErrInsufficientDepth = errors.New("insufficient depth")
+
+// OrderBookProcessor keeps each side in a map keyed by price, so applying a level is an O(1) write or
+// delete. The sorted views returned by Levels are rebuilt lazily, on the first read after a change.
 type OrderBookProcessor struct {
-	Bids   []Level
-	Offers []Level
+	LastSequence int64
+	UpdatedAt    time.Time
+	// MaxDepth bounds the levels kept per side, so the processor is a top-of-book view rather than a full book.
+	// Levels cut off at the limit are forgotten, so after deletions shrink a side the levels near the
+	// cutoff can be missing or stale until the next snapshot.
+	MaxDepth int
+
+	bidIndex    map[string]Level
+	offerIndex  map[string]Level
+	bids        []Level
+	offers      []Level
+	bidsDirty   bool
+	offersDirty bool
 }
 
-func NewOrderBookProcessor(snapshot string) *OrderBookProcessor {
+func NewOrderBookProcessor(snapshot string, maxDepth int) (*OrderBookProcessor, error) {
 	var snapshotData struct {
 		Events []struct {
 			Updates []LevelJson
@@ -50,82 +76,218 @@ func NewOrderBookProcessor(snapshot string) *OrderBookProcessor {
 	}
 
 	if err := json.Unmarshal([]byte(snapshot), &snapshotData); err != nil {
-		log.Printf("Failed to parse snapshot JSON: %v", err)
-		return nil
+		return nil, fmt.Errorf("failed to parse snapshot JSON: %w", err)
 	}
 
-	var bids, offers []Level
+	processor := &OrderBookProcessor{
+		UpdatedAt:  time.Now(),
+		MaxDepth:   maxDepth,
+		bidIndex:   make(map[string]Level),
+		offerIndex: make(map[string]Level),
+	}
 	for _, event := range snapshotData.Events {
 		for _, update := range event.Updates {
-			level, err := levelFromJson(update)
-			if err != nil {
-				log.Printf("Error converting LevelJson to Level: %v", err)
-				continue
-			}
-			if level.Side == LevelSideBid {
-				bids = append(bids, *level)
-			} else if level.Side == LevelSideOffer {
-				offers = append(offers, *level)
-			}
+			processor.apply(update)
 		}
 	}
 
-	processor := &OrderBookProcessor{
-		Bids:   bids,
-		Offers: offers,
-	}
-	processor.sort()
+	return processor, nil
+}
 
-	return processor
+func (app *TradeApp) bookMaxDepth() int {
+	if app.BookMaxDepth <= 0 {
+		return DefaultBookMaxDepth
+	}
+	return app.BookMaxDepth
 }
 
-func displayOrderBook(app *TradeApp, processor *OrderBookProcessor, n int) {
+// displayOrderBooks redraws books in place of the previous frame, followed by the stats line when stats is set.
+func displayOrderBooks(app *TradeApp, books map[string]*OrderBookProcessor, stats *feedStats, query *vwapQuery, productIds []string, n int) {
 	if !app.FirstPrint {
-		fmt.Printf("\033[%dA", 2*n)
+		fmt.Printf("\033[%dA\033[J", app.bookLines)
 	} else {
 		app.FirstPrint = false
 	}
 
+	lines := 0
+	for _, productId := range productIds {
+		processor, ok := books[productId]
+		if !ok {
+			continue
+		}
+		fmt.Println(Cyan + productId + Reset)
+		lines += displayOrderBook(app, processor, n, app.bookPrecision(productId), query) + 1
+	}
+	if stats != nil {
+		fmt.Println(Gray + stats.String(time.Now()) + Reset)
+		lines++
+	}
+	app.bookLines = lines
+}
+
+type feedStats struct {
+	windowStart time.Time
+	windowCount int
+	rate        float64
+	lastUpdate  time.Time
+	latency     time.Duration
+}
+
+// record counts an applied update; sent is the feed's timestamp for it, used to estimate latency.
+func (s *feedStats) record(now, sent time.Time) {
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	s.windowCount++
+	if elapsed := now.Sub(s.windowStart); elapsed >= time.Second {
+		s.rate = float64(s.windowCount) / elapsed.Seconds()
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	s.lastUpdate = now
+	if !sent.IsZero() {
+		s.latency = now.Sub(sent)
+	}
+}
+
+func (s *feedStats) String(now time.Time) string {
+	status := fmt.Sprintf("updates/s: %.0f | last: %dms ago", s.rate, now.Sub(s.lastUpdate).Milliseconds())
+	if s.latency != 0 {
+		status += fmt.Sprintf(" | feed latency: %dms", s.latency.Milliseconds())
+	}
+	return status
+}
+
+func displayOrderBook(app *TradeApp, processor *OrderBookProcessor, n int, precision config.DisplayPrecision, query *vwapQuery) int {
 	topBids := processor.GetTopNBids(n)
 	topOffers := processor.GetTopNOffers(n)
+	bidQty, offerQty := processor.CumulativeDepth()
+	bidNotional, offerNotional := processor.CumulativeNotional()
+	if app.bookGroupSize > 0 {
+		bids, offers := processor.GroupedLevels(app.bookGroupSize)
+		topBids, topOffers = topLevels(bids, n), topLevels(offers, n)
+		bidQty, offerQty = cumulative(bids, levelQty), cumulative(offers, levelQty)
+		bidNotional, offerNotional = cumulative(bids, levelNotional), cumulative(offers, levelNotional)
+	}
+
+	printLevels(topOffers, offerQty, offerNotional, Red+"Ask: %s @ %s | Cum: %s / %s\n"+Reset, true, precision)
+	printSpread(processor, precision)
+	printLevels(topBids, bidQty, bidNotional, Green+"Bid: %s @ %s | Cum: %s / %s\n"+Reset, false, precision)
+	printImbalance(processor, n)
+	lines := len(topOffers) + len(topBids) + 2
 
-	for i, j := 0, len(topOffers)-1; i < j; i, j = i+1, j-1 {
-		topOffers[i], topOffers[j] = topOffers[j], topOffers[i]
+	if query != nil {
+		printVwap(processor, query, precision)
+		lines++
+	}
+	return lines
+}
+
+func formatPlaces(value float64, places int32) string {
+	return strconv.FormatFloat(value, 'f', int(places), 64)
+}
+
+func printVwap(processor *OrderBookProcessor, query *vwapQuery, precision config.DisplayPrecision) {
+	vwap, filled, err := processor.Vwap(query.Side, query.Size)
+	size := formatPlaces(query.Size, precision.Quantity)
+	switch {
+	case errors.Is(err, ErrInsufficientDepth):
+		fmt.Printf(Yellow+"VWAP %s %s: %s (only %s available)\n"+Reset, query.Side, size, formatPlaces(vwap, precision.Price), formatPlaces(filled, precision.Quantity))
+	case err != nil:
+		fmt.Printf(Yellow+"VWAP %s %s: %v\n"+Reset, query.Side, size, err)
+	default:
+		fmt.Printf(Yellow+"VWAP %s %s: %s\n"+Reset, query.Side, size, formatPlaces(vwap, precision.Price))
+	}
+}
+
+func printSpread(processor *OrderBookProcessor, precision config.DisplayPrecision) {
+	spread, mid, ok := processor.Spread()
+	if !ok {
+		fmt.Println(Yellow + "Spread: - | Mid: -" + Reset)
+		return
+	}
+	spreadPct := spread.Div(mid).Mul(decimal.NewFromInt(100))
+	fmt.Printf(Yellow+"Spread: %s (%s%%) | Mid: %s\n"+Reset, spread.StringFixed(precision.Price), spreadPct.StringFixed(2), mid.StringFixed(precision.Price))
+}
+
+func printImbalance(processor *OrderBookProcessor, n int) {
+	if bids, offers := processor.Levels(); len(bids) == 0 && len(offers) == 0 {
+		fmt.Println(Yellow + "Imbalance: -" + Reset)
+		return
+	}
+	imbalance := processor.Imbalance(n)
+	color := Yellow
+	switch {
+	case imbalance > 0.5:
+		color = Green
+	case imbalance < 0.5:
+		color = Red
+	}
+	fmt.Printf(color+"Imbalance (top %d): %s%% bid\n"+Reset, n, formatPlaces(imbalance*100, 2))
+}
+
+type wsEnvelope struct {
+	Channel     string
+	Type        string
+	SequenceNum *int64
+	ProductId   string
+	EventType   string
+	Timestamp   time.Time
+	Message     string
+}
+
+func parseWsEnvelope(data string) (wsEnvelope, error) {
+	var message struct {
+		Channel     string `json:"channel"`
+		Type        string `json:"type"`
+		SequenceNum *int64 `json:"sequence_num"`
+		Timestamp   string `json:"timestamp"`
+		Message     string `json:"message"`
+		Events      []struct {
+			Type      string `json:"type"`
+			ProductId string `json:"product_id"`
+		}
+	}
+
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		return wsEnvelope{}, err
 	}
 
-	printLevels(topOffers, Red+"Ask: %.2f @ %.2f\n"+Reset)
-	printLevels(topBids, Green+"Bid: %.2f @ %.2f\n"+Reset)
+	envelope := wsEnvelope{Channel: message.Channel, Type: message.Type, SequenceNum: message.SequenceNum, Message: message.Message}
+	if timestamp, err := time.Parse(time.RFC3339Nano, message.Timestamp); err == nil {
+		envelope.Timestamp = timestamp
+	}
+	for _, event := range message.Events {
+		if event.ProductId != "" {
+			envelope.ProductId = event.ProductId
+			envelope.EventType = event.Type
+			break
+		}
+	}
+	return envelope, nil
 }
 
 func levelFromJson(l LevelJson) (*Level, error) {
-	px, err := strconv.ParseFloat(l.Px, 64)
+	px, err := decimal.NewFromString(l.Px)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert Px to float64: %v", err)
+		return nil, fmt.Errorf("failed to convert Px to decimal: %v", err)
 	}
 
-	qty, err := strconv.ParseFloat(l.Qty, 64)
+	qty, err := decimal.NewFromString(l.Qty)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert Qty to float64: %v", err)
+		return nil, fmt.Errorf("failed to convert Qty to decimal: %v", err)
 	}
 
 	return &Level{Side: l.Side, Px: px, Qty: qty}, nil
 }
 
-func filterZeroQty(levels []Level) []Level {
-	var result []Level
-	for _, level := range levels {
-		if level.Qty > 0 {
-			result = append(result, level)
+func printLevels(levels []Level, cumQty, cumNotional []float64, format string, reverse bool, precision config.DisplayPrecision) {
+	for i := range levels {
+		if reverse {
+			i = len(levels) - 1 - i
 		}
-	}
-	return result
-}
-
-func printLevels(levels []Level, format string) {
-	for _, level := range levels {
-		roundedQty := math.Round(level.Qty*100) / 100
-		roundedPx := math.Round(level.Px*100) / 100
-		fmt.Printf(format, roundedQty, roundedPx)
+		level := levels[i]
+		fmt.Printf(format, level.Qty.StringFixed(precision.Quantity), level.Px.StringFixed(precision.Price), formatPlaces(cumQty[i], precision.Quantity), formatPlaces(cumNotional[i], DefaultDisplayPlaces))
 	}
 }
 
@@ -151,8 +313,7 @@ func (p *OrderBookProcessor) ApplyUpdate(data string) {
 			p.apply(update)
 		}
 	}
-	p.filterClosed()
-	p.sort()
+	p.UpdatedAt = time.Now()
 }
 
 func (p *OrderBookProcessor) apply(levelJson LevelJson) {
@@ -162,51 +323,186 @@ func (p *OrderBookProcessor) apply(levelJson LevelJson) {
 		return
 	}
 
-	target := &p.Bids
-	if level.Side == LevelSideOffer {
-		target = &p.Offers
-	} else if level.Side != LevelSideBid {
+	index, dirty := p.bidIndex, &p.bidsDirty
+	switch level.Side {
+	case LevelSideBid:
+	case LevelSideOffer:
+		index, dirty = p.offerIndex, &p.offersDirty
+	default:
 		log.Printf(Red+"Error: Unrecognized side: %s"+Reset, level.Side)
 		return
 	}
 
-	found := false
-	for i, existing := range *target {
-		if existing.Px == level.Px {
-			(*target)[i] = *level
-			found = true
-			break
-		}
+	key := level.Px.String()
+	if level.Qty.IsPositive() {
+		index[key] = *level
+		*dirty = true
+	} else if _, ok := index[key]; ok {
+		delete(index, key)
+		*dirty = true
+	}
+}
+
+// Levels returns both sides sorted best price first, re-sorting a side only if it changed since
+// the last read. The returned slices are replaced, not modified, by later updates.
+func (p *OrderBookProcessor) Levels() ([]Level, []Level) {
+	if p.bidsDirty {
+		p.bids = sortedLevels(p.bidIndex, p.MaxDepth, decimal.Decimal.GreaterThan)
+		p.bidsDirty = false
 	}
-	if !found {
-		*target = append(*target, *level)
+	if p.offersDirty {
+		p.offers = sortedLevels(p.offerIndex, p.MaxDepth, decimal.Decimal.LessThan)
+		p.offersDirty = false
 	}
+	return p.bids, p.offers
 }
 
-func (p *OrderBookProcessor) filterClosed() {
-	p.Bids = filterZeroQty(p.Bids)
-	p.Offers = filterZeroQty(p.Offers)
+// sortedLevels orders index so that before(a, b) holds when a is ahead of b, and drops the levels
+// past maxDepth from both the view and the index.
+func sortedLevels(index map[string]Level, maxDepth int, before func(decimal.Decimal, decimal.Decimal) bool) []Level {
+	levels := make([]Level, 0, len(index))
+	for _, level := range index {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return before(levels[i].Px, levels[j].Px)
+	})
+	if maxDepth > 0 && len(levels) > maxDepth {
+		for _, level := range levels[maxDepth:] {
+			delete(index, level.Px.String())
+		}
+		levels = levels[:maxDepth]
+	}
+	return levels
 }
 
 func (p *OrderBookProcessor) GetTopNBids(n int) []Level {
-	if n > len(p.Bids) {
-		return p.Bids
-	}
-	return p.Bids[:n]
+	bids, _ := p.Levels()
+	return topLevels(bids, n)
 }
 
 func (p *OrderBookProcessor) GetTopNOffers(n int) []Level {
-	if n > len(p.Offers) {
-		return p.Offers
+	_, offers := p.Levels()
+	return topLevels(offers, n)
+}
+
+func topLevels(levels []Level, n int) []Level {
+	if n > len(levels) {
+		return levels
 	}
-	return p.Offers[:n]
+	return levels[:n]
 }
 
-func (p *OrderBookProcessor) sort() {
-	sort.Slice(p.Bids, func(i, j int) bool {
-		return p.Bids[i].Px > p.Bids[j].Px
-	})
-	sort.Slice(p.Offers, func(i, j int) bool {
-		return p.Offers[i].Px < p.Offers[j].Px
-	})
+func levelQty(l Level) decimal.Decimal { return l.Qty }
+
+func levelNotional(l Level) decimal.Decimal { return l.Qty.Mul(l.Px) }
+
+func (p *OrderBookProcessor) CumulativeDepth() ([]float64, []float64) {
+	bids, offers := p.Levels()
+	return cumulative(bids, levelQty), cumulative(offers, levelQty)
+}
+
+func (p *OrderBookProcessor) CumulativeNotional() ([]float64, []float64) {
+	bids, offers := p.Levels()
+	return cumulative(bids, levelNotional), cumulative(offers, levelNotional)
+}
+
+// GroupedLevels sums quantity into price buckets, rounding bids down and offers up to the bucket edge.
+// The notional of each bucket is valued at its edge price.
+func (p *OrderBookProcessor) GroupedLevels(bucketSize float64) ([]Level, []Level) {
+	bids, offers := p.Levels()
+	bucket := decimal.NewFromFloat(bucketSize)
+	if !bucket.IsPositive() {
+		return bids, offers
+	}
+	grouped := groupLevels(bids, func(px decimal.Decimal) decimal.Decimal { return px.Div(bucket).Floor().Mul(bucket) })
+	return grouped, groupLevels(offers, func(px decimal.Decimal) decimal.Decimal { return px.Div(bucket).Ceil().Mul(bucket) })
+}
+
+func groupLevels(levels []Level, edge func(decimal.Decimal) decimal.Decimal) []Level {
+	var grouped []Level
+	for _, level := range levels {
+		px := edge(level.Px)
+		if last := len(grouped) - 1; last >= 0 && grouped[last].Px.Equal(px) {
+			grouped[last].Qty = grouped[last].Qty.Add(level.Qty)
+			continue
+		}
+		grouped = append(grouped, Level{Side: level.Side, Px: px, Qty: level.Qty})
+	}
+	return grouped
+}
+
+func cumulative(levels []Level, value func(Level) decimal.Decimal) []float64 {
+	result := make([]float64, len(levels))
+	total := decimal.Zero
+	for i, level := range levels {
+		total = total.Add(value(level))
+		result[i] = total.InexactFloat64()
+	}
+	return result
+}
+
+func (p *OrderBookProcessor) Vwap(side string, size float64) (float64, float64, error) {
+	bids, offers := p.Levels()
+	var levels []Level
+	switch side {
+	case TradeSideBuy:
+		levels = offers
+	case TradeSideSell:
+		levels = bids
+	default:
+		return 0, 0, fmt.Errorf("unrecognized side: %s", side)
+	}
+
+	target := decimal.NewFromFloat(size)
+	filled, notional := decimal.Zero, decimal.Zero
+	for _, level := range levels {
+		qty := decimal.Min(level.Qty, target.Sub(filled))
+		filled = filled.Add(qty)
+		notional = notional.Add(qty.Mul(level.Px))
+		if filled.GreaterThanOrEqual(target) {
+			break
+		}
+	}
+
+	if filled.IsZero() {
+		return 0, 0, ErrInsufficientDepth
+	}
+
+	vwap := notional.Div(filled).InexactFloat64()
+	if filled.LessThan(target) {
+		return vwap, filled.InexactFloat64(), fmt.Errorf("%w: %s of %s available", ErrInsufficientDepth, filled.String(), target.String())
+	}
+	return vwap, filled.InexactFloat64(), nil
+}
+
+// Imbalance returns bid quantity as a fraction of total quantity over the top n levels of each side.
+// An empty book is reported as balanced (0.5).
+func (p *OrderBookProcessor) Imbalance(n int) float64 {
+	bidQty, offerQty := decimal.Zero, decimal.Zero
+	for _, level := range p.GetTopNBids(n) {
+		bidQty = bidQty.Add(level.Qty)
+	}
+	for _, level := range p.GetTopNOffers(n) {
+		offerQty = offerQty.Add(level.Qty)
+	}
+
+	total := bidQty.Add(offerQty)
+	if !total.IsPositive() {
+		return 0.5
+	}
+	return bidQty.Div(total).InexactFloat64()
+}
+
+func (p *OrderBookProcessor) Spread() (decimal.Decimal, decimal.Decimal, bool) {
+	bids, offers := p.Levels()
+	if len(bids) == 0 || len(offers) == 0 {
+		return decimal.Zero, decimal.Zero, false
+	}
+	bestBid, bestAsk := bids[0].Px, offers[0].Px
+	mid := bestAsk.Add(bestBid).Div(decimal.NewFromInt(2))
+	if mid.IsZero() {
+		return decimal.Zero, decimal.Zero, false
+	}
+	return bestAsk.Sub(bestBid), mid, true
 }
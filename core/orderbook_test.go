@@ -0,0 +1,224 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// l2Message builds an l2_data message carrying the given levels, each as side, px, qty.
+func l2Message(levels ...[3]string) string {
+	updates := make([]string, len(levels))
+	for i, level := range levels {
+		updates[i] = fmt.Sprintf(`{"side":%q,"px":%q,"qty":%q}`, level[0], level[1], level[2])
+	}
+	return `{"channel":"l2_data","events":[{"updates":[` + strings.Join(updates, ",") + `]}]}`
+}
+
+// levelStrings renders levels as "px:qty" for comparison.
+func levelStrings(levels []Level) []string {
+	out := make([]string, len(levels))
+	for i, level := range levels {
+		out[i] = level.Px.String() + ":" + level.Qty.String()
+	}
+	return out
+}
+
+func TestOrderBookProcessor(t *testing.T) {
+	snapshot := l2Message(
+		[3]string{LevelSideBid, "99", "2"},
+		[3]string{LevelSideOffer, "102", "1"},
+		[3]string{LevelSideBid, "100", "1"},
+		[3]string{LevelSideOffer, "101", "3"},
+	)
+
+	tests := []struct {
+		name       string
+		snapshot   string
+		maxDepth   int
+		updates    []string
+		wantBids   []string
+		wantOffers []string
+	}{
+		{
+			name:       "snapshot is sorted best price first",
+			snapshot:   snapshot,
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "empty snapshot",
+			snapshot:   `{"events":[]}`,
+			wantBids:   []string{},
+			wantOffers: []string{},
+		},
+		{
+			name:       "update adds levels in order",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideBid, "99.5", "4"}, [3]string{LevelSideOffer, "103", "5"})},
+			wantBids:   []string{"100:1", "99.5:4", "99:2"},
+			wantOffers: []string{"101:3", "102:1", "103:5"},
+		},
+		{
+			name:       "update modifies an existing level",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideBid, "100.00", "7"}, [3]string{LevelSideOffer, "102", "0.5"})},
+			wantBids:   []string{"100:7", "99:2"},
+			wantOffers: []string{"101:3", "102:0.5"},
+		},
+		{
+			name:       "zero quantity removes a level",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideBid, "100", "0"}, [3]string{LevelSideOffer, "101", "0"})},
+			wantBids:   []string{"99:2"},
+			wantOffers: []string{"102:1"},
+		},
+		{
+			name:       "zero quantity for an unknown level is ignored",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideBid, "98", "0"})},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "offer and bid updates only touch their own side",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideOffer, "100", "1"})},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"100:1", "101:3", "102:1"},
+		},
+		{
+			name:       "unrecognized side is ignored",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{"ask", "100.5", "1"}, [3]string{"BID", "99.5", "1"})},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "invalid price is ignored",
+			snapshot:   snapshot,
+			updates:    []string{l2Message([3]string{LevelSideBid, "abc", "1"})},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "update on another channel is ignored",
+			snapshot:   snapshot,
+			updates:    []string{`{"channel":"heartbeats","events":[{"updates":[{"side":"bid","px":"100","qty":"0"}]}]}`},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "malformed update is ignored",
+			snapshot:   snapshot,
+			updates:    []string{`{"channel":"l2_data","events":[`},
+			wantBids:   []string{"100:1", "99:2"},
+			wantOffers: []string{"101:3", "102:1"},
+		},
+		{
+			name:       "levels beyond max depth are dropped",
+			snapshot:   snapshot,
+			maxDepth:   1,
+			updates:    []string{l2Message([3]string{LevelSideBid, "98", "1"}, [3]string{LevelSideOffer, "100.5", "1"})},
+			wantBids:   []string{"100:1"},
+			wantOffers: []string{"100.5:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewOrderBookProcessor(tt.snapshot, tt.maxDepth)
+			if err != nil {
+				t.Fatalf("NewOrderBookProcessor returned %v", err)
+			}
+			for _, update := range tt.updates {
+				processor.ApplyUpdate(update)
+			}
+
+			bids, offers := processor.Levels()
+			if got := levelStrings(bids); strings.Join(got, ",") != strings.Join(tt.wantBids, ",") {
+				t.Errorf("bids = %v, want %v", got, tt.wantBids)
+			}
+			if got := levelStrings(offers); strings.Join(got, ",") != strings.Join(tt.wantOffers, ",") {
+				t.Errorf("offers = %v, want %v", got, tt.wantOffers)
+			}
+		})
+	}
+}
+
+func TestNewOrderBookProcessorMalformedJson(t *testing.T) {
+	for _, snapshot := range []string{"", "not json", `{"events":[{"updates":`, `{"events":"x"}`} {
+		processor, err := NewOrderBookProcessor(snapshot, DefaultBookMaxDepth)
+		if err == nil {
+			t.Errorf("NewOrderBookProcessor(%q) returned no error", snapshot)
+		}
+		if processor != nil {
+			t.Errorf("NewOrderBookProcessor(%q) returned a book with its error", snapshot)
+		}
+	}
+}
+
+// newBenchmarkBook returns a full default-depth book and a rotating set of update messages, each
+// changing one bid and one offer.
+func newBenchmarkBook(b *testing.B) (*OrderBookProcessor, []string) {
+	b.Helper()
+	var levels [][3]string
+	for i := 0; i < DefaultBookMaxDepth; i++ {
+		levels = append(levels,
+			[3]string{LevelSideBid, fmt.Sprintf("%d.00", 1000-i), "1"},
+			[3]string{LevelSideOffer, fmt.Sprintf("%d.00", 1001+i), "1"})
+	}
+	processor, err := NewOrderBookProcessor(l2Message(levels...), DefaultBookMaxDepth)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	updates := make([]string, 64)
+	for i := range updates {
+		updates[i] = l2Message(
+			[3]string{LevelSideBid, fmt.Sprintf("%d.00", 1000-i%DefaultBookMaxDepth), fmt.Sprintf("%d", i%3)},
+			[3]string{LevelSideOffer, fmt.Sprintf("%d.00", 1001+i%DefaultBookMaxDepth), "2"})
+	}
+	return processor, updates
+}
+
+// BenchmarkApplyUpdate measures applying a feed message, which only writes to the price indexes.
+func BenchmarkApplyUpdate(b *testing.B) {
+	processor, updates := newBenchmarkBook(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processor.ApplyUpdate(updates[i%len(updates)])
+	}
+}
+
+// BenchmarkApplyUpdateAndRead adds the top-of-book read the live display does after every message,
+// which pays for re-sorting both changed sides.
+func BenchmarkApplyUpdateAndRead(b *testing.B) {
+	processor, updates := newBenchmarkBook(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processor.ApplyUpdate(updates[i%len(updates)])
+		processor.GetTopNBids(5)
+		processor.GetTopNOffers(5)
+	}
+}
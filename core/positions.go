@@ -0,0 +1,139 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const PositionQuoteCurrency = "USD"
+
+type Position struct {
+	Asset         string           `json:"asset"`
+	Quantity      decimal.Decimal  `json:"quantity"`
+	MarkProduct   string           `json:"mark_product"`
+	Mark          *decimal.Decimal `json:"mark,omitempty"`
+	Notional      *decimal.Decimal `json:"notional,omitempty"`
+	CostBasis     *decimal.Decimal `json:"cost_basis,omitempty"`
+	UnrealizedPnl *decimal.Decimal `json:"unrealized_pnl,omitempty"`
+	StaleMark     bool             `json:"stale_mark"`
+}
+
+func markPrice(priceData PriceData) (decimal.Decimal, bool) {
+	bid, bidErr := decimal.NewFromString(priceData.Bid)
+	ask, askErr := decimal.NewFromString(priceData.Ask)
+	if bidErr == nil && askErr == nil && bid.IsPositive() && ask.IsPositive() {
+		return bid.Add(ask).Div(decimal.NewFromInt(2)), true
+	}
+	price, err := decimal.NewFromString(priceData.Price)
+	if err != nil || !price.IsPositive() {
+		return decimal.Zero, false
+	}
+	return price, true
+}
+
+func (app *TradeApp) GetPositions() ([]Position, error) {
+	balances, err := app.GetAllBalances()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := []Position{}
+	for _, balance := range balances {
+		asset := strings.ToUpper(balance.Symbol)
+		quantity, err := decimal.NewFromString(balance.Amount)
+		if err != nil || quantity.IsZero() || asset == PositionQuoteCurrency {
+			continue
+		}
+
+		position := Position{Asset: asset, Quantity: quantity, MarkProduct: asset + "-" + PositionQuoteCurrency}
+		if priceData, ok := app.prices.Get(position.MarkProduct); ok {
+			if mark, ok := markPrice(priceData); ok {
+				notional := quantity.Mul(mark)
+				position.Mark, position.Notional = &mark, &notional
+				position.StaleMark = time.Since(priceData.FetchedAt) > app.maxPriceAge()
+			}
+		}
+		if basis, ok := app.CostBasis[asset]; ok && position.Mark != nil {
+			pnl := position.Mark.Sub(basis).Mul(quantity)
+			position.CostBasis, position.UnrealizedPnl = &basis, &pnl
+		}
+		positions = append(positions, position)
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positionNotional(positions[i]).GreaterThan(positionNotional(positions[j]))
+	})
+	return positions, nil
+}
+
+func positionNotional(position Position) decimal.Decimal {
+	if position.Notional == nil {
+		return decimal.Zero
+	}
+	return *position.Notional
+}
+
+func formatOptional(value *decimal.Decimal, places int32) string {
+	if value == nil {
+		return "-"
+	}
+	return value.StringFixed(places)
+}
+
+func (app *TradeApp) ViewPositions() error {
+	positions, err := app.GetPositions()
+	if err != nil {
+		return err
+	}
+	if app.jsonOutput() {
+		return writeJson(positions)
+	}
+
+	if len(positions) == 0 {
+		fmt.Println("No positions found!")
+		return nil
+	}
+
+	totalNotional, totalPnl := decimal.Zero, decimal.Zero
+	fmt.Println(Blue + "Asset  |             Quantity |         Mark |       Notional |   Cost Basis | Unrealized PnL" + Reset)
+	for _, position := range positions {
+		mark := formatOptional(position.Mark, DefaultDisplayPlaces)
+		if position.StaleMark {
+			mark += "*"
+		}
+		color := Blue
+		if position.UnrealizedPnl != nil {
+			totalPnl = totalPnl.Add(*position.UnrealizedPnl)
+			color = Green
+			if position.UnrealizedPnl.IsNegative() {
+				color = Red
+			}
+		}
+		totalNotional = totalNotional.Add(positionNotional(position))
+		fmt.Printf(color+"%-7s| %20s | %12s | %14s | %12s | %14s\n"+Reset, position.Asset, position.Quantity.String(), mark,
+			formatOptional(position.Notional, DefaultDisplayPlaces), formatOptional(position.CostBasis, DefaultDisplayPlaces), formatOptional(position.UnrealizedPnl, DefaultDisplayPlaces))
+	}
+	fmt.Printf(Blue+"Total Notional: %s %s, Unrealized PnL: %s %s\n"+Reset, totalNotional.StringFixed(2), PositionQuoteCurrency, totalPnl.StringFixed(2), PositionQuoteCurrency)
+	fmt.Printf("Marks are the cached %s mid; '*' marks prices older than %s. PnL needs a CostBasis entry in creds.json.\n", PositionQuoteCurrency, app.maxPriceAge())
+	return nil
+}
@@ -17,80 +17,241 @@ limitations under the License.
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/shopspring/decimal"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	PriceFeedURL         = "https://api.exchange.coinbase.com"
+	priceStaleMultiplier = 3
+	DefaultPriceFetchGap = 10 * time.Second
+	MinPriceFetchSeconds = 0.5
+	priceFetchWorkers    = 4
+	priceFeedRateLimit   = 10
+)
+
 type PriceData struct {
-	Ask   string    `json:"ask"`
-	Bid   string    `json:"bid"`
-	Price string    `json:"price"`
-	Time  time.Time `json:"time"`
+	Ask       string    `json:"ask"`
+	Bid       string    `json:"bid"`
+	Price     string    `json:"price"`
+	Time      time.Time `json:"time"`
+	FetchedAt time.Time `json:"-"`
+}
+
+type priceStore struct {
+	mutex  sync.RWMutex
+	prices map[string]PriceData
+}
+
+func newPriceStore() *priceStore {
+	return &priceStore{prices: make(map[string]PriceData)}
 }
 
-var priceCache = make(map[string]PriceData)
+func (s *priceStore) Get(productId string) (PriceData, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	data, ok := s.prices[productId]
+	return data, ok
+}
+
+func (s *priceStore) Set(productId string, data PriceData) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prices[productId] = data
+}
 
 func getAndCheckPrice(app *TradeApp, productId string) {
-	currentPrice, err := fetchPrice(productId)
+	currentPrice, err := app.fetchPrice(productId)
 	if err != nil {
 		log.Printf("Failed to fetch price for %s: %v", productId, err)
 		return
 	}
 
 	processStopOrders(app, productId, currentPrice)
+	app.checkPriceAlerts(productId, currentPrice)
+}
+
+func (app *TradeApp) priceFetchInterval() time.Duration {
+	if app.PriceFetchSeconds <= 0 {
+		return DefaultPriceFetchGap
+	}
+	return time.Duration(app.PriceFetchSeconds * float64(time.Second))
+}
+
+// fetchAllPrices refreshes products on a bounded pool of workers, paced by the price feed limiter, and returns once all have finished.
+func (app *TradeApp) fetchAllPrices(ctx context.Context, products []string) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < priceFetchWorkers && i < len(products); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for product := range jobs {
+				if err := app.priceLimiter.Wait(ctx); err != nil {
+					continue
+				}
+				getAndCheckPrice(app, product)
+			}
+		}()
+	}
+
+	for _, product := range products {
+		select {
+		case jobs <- product:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (app *TradeApp) RefreshPrices() {
+	app.fetchAllPrices(app.ctx, app.SupportedProducts)
+	for _, product := range app.SupportedProducts {
+		if priceData, ok := app.prices.Get(product); ok {
+			fmt.Printf(Blue+"%s - Bid: %s | Ask: %s | Last: %s\n"+Reset, product, priceData.Bid, priceData.Ask, priceData.Price)
+		}
+	}
+}
+
+func (app *TradeApp) priceFeedUrl() string {
+	if app.PriceFeedUrl == "" {
+		return PriceFeedURL
+	}
+	return strings.TrimRight(app.PriceFeedUrl, "/")
 }
 
-func fetchPrice(productId string) (decimal.Decimal, error) {
-	url := "https://api.exchange.coinbase.com/products/" + productId + "/ticker"
-	resp, err := http.Get(url)
+func (app *TradeApp) fetchPrice(productId string) (decimal.Decimal, error) {
+	data, err := fetchTicker(app.httpClient, app.priceFeedUrl(), productId)
 	if err != nil {
 		return decimal.Decimal{}, err
 	}
+	app.prices.Set(productId, data)
+	return decimal.NewFromString(data.Price)
+}
+
+func fetchTicker(client *http.Client, baseUrl, productId string) (PriceData, error) {
+	url := baseUrl + "/products/" + productId + "/ticker"
+	resp, err := client.Get(url)
+	if err != nil {
+		return PriceData{}, err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return decimal.Decimal{}, fmt.Errorf("non-200 response code when fetching price for %s: %d", productId, resp.StatusCode)
+		return PriceData{}, fmt.Errorf("non-200 response code when fetching price for %s: %d", productId, resp.StatusCode)
 	}
 
 	var data PriceData
 	decoder := json.NewDecoder(resp.Body)
 	if err = decoder.Decode(&data); err != nil {
-		return decimal.Decimal{}, fmt.Errorf("failed to decode price data for %s: %v", productId, err)
+		return PriceData{}, fmt.Errorf("failed to decode price data for %s: %v", productId, err)
 	}
 
-	priceCache[productId] = data
-	return decimal.NewFromString(data.Price)
+	data.FetchedAt = time.Now()
+	return data, nil
 }
 
 func processStopOrders(app *TradeApp, productId string, currentPrice decimal.Decimal) {
+	for _, order := range app.triggeredStopOrders(productId, currentPrice) {
+		executeStopOrder(app, order)
+	}
+}
+
+// triggeredStopOrders removes and returns the stops crossed by currentPrice. The orders are sent by
+// the caller after the lock is released so exec reports are not held up behind network calls.
+func (app *TradeApp) triggeredStopOrders(productId string, currentPrice decimal.Decimal) []stopOrder {
+	quoteIncrement := app.productIncrements(productId).Quote
+
 	app.stopOrdersMutex.Lock()
 	defer app.stopOrdersMutex.Unlock()
 
+	var triggered []stopOrder
 	var toRemove []int
 	for i := len(stopOrders) - 1; i >= 0; i-- {
-		order := stopOrders[i]
-		if order.Product != productId {
+		order := &stopOrders[i]
+		if order.Product != productId || order.Triggered {
 			continue
 		}
 
+		if order.TrailOffset.IsPositive() {
+			updateTrailingStop(order, currentPrice, quoteIncrement)
+		}
+
 		if order.Side == TradeSideBuy && currentPrice.GreaterThanOrEqual(order.StopPrice) {
 			log.Printf("Triggering buy order for %s at price: %s", productId, order.StopPrice.String())
-			executeStopBuyOco(app, order)
-			toRemove = append(toRemove, i)
 		} else if order.Side == TradeSideSell && currentPrice.LessThanOrEqual(order.StopPrice) {
 			log.Printf("Triggering sell order for %s at price: %s", productId, order.StopPrice.String())
-			executeStopSellOco(app, order)
-			toRemove = append(toRemove, i)
+		} else {
+			continue
 		}
+
+		order.Triggered = true
+		triggered = append(triggered, *order)
+		toRemove = append(toRemove, i)
 	}
 
-	for i := len(toRemove) - 1; i >= 0; i-- {
-		removeStopOrder(toRemove[i])
+	for _, index := range toRemove {
+		removeStopOrder(index)
 	}
+	return triggered
+}
+
+func (app *TradeApp) newTrailingStop(params parsedTradeParams, amount float64, limitPrice decimal.Decimal, trailArg string) (stopOrder, error) {
+	percent := strings.HasSuffix(trailArg, "%")
+	offset, err := decimal.NewFromString(strings.TrimSuffix(trailArg, "%"))
+	if err != nil || !offset.IsPositive() || (percent && offset.GreaterThanOrEqual(decimal.NewFromInt(100))) {
+		return stopOrder{}, fmt.Errorf("invalid trail offset %q", trailArg)
+	}
+
+	priceData, ok := app.prices.Get(params.Product)
+	if !ok {
+		return stopOrder{}, fmt.Errorf("no reference price for %s, add it to SupportedProducts in creds.json", params.Product)
+	}
+	currentPrice, err := decimal.NewFromString(priceData.Price)
+	if err != nil {
+		return stopOrder{}, fmt.Errorf("invalid reference price for %s: %v", params.Product, err)
+	}
+
+	order := stopOrder{
+		Product:       params.Product,
+		Side:          params.Side,
+		BaseQuantity:  params.BaseQuantity,
+		Amount:        amount,
+		LimitPrice:    limitPrice,
+		TrailOffset:   offset,
+		TrailPercent:  percent,
+		HighWaterMark: currentPrice,
+	}
+	order.StopPrice = trailingStopPrice(order)
+	return order, nil
+}
+
+func updateTrailingStop(order *stopOrder, currentPrice, quoteIncrement decimal.Decimal) {
+	if order.Side == TradeSideSell && currentPrice.GreaterThan(order.HighWaterMark) ||
+		order.Side == TradeSideBuy && currentPrice.LessThan(order.HighWaterMark) {
+		order.HighWaterMark = currentPrice
+		order.StopPrice = trailingStopPrice(*order)
+		log.Printf("Trailing stop for %s moved to %s", order.Product, formatIncrement(order.StopPrice.String(), quoteIncrement))
+	}
+}
+
+func trailingStopPrice(order stopOrder) decimal.Decimal {
+	offset := order.TrailOffset
+	if order.TrailPercent {
+		offset = order.HighWaterMark.Mul(order.TrailOffset).Div(decimal.NewFromInt(100))
+	}
+	if order.Side == TradeSideSell {
+		return order.HighWaterMark.Sub(offset)
+	}
+	return order.HighWaterMark.Add(offset)
 }
 
 func removeStopOrder(index int) {
@@ -101,59 +262,73 @@ func removeStopOrder(index int) {
 	stopOrders = append(stopOrders[:index], stopOrders[index+1:]...)
 }
 
-func executeStopBuyOco(app *TradeApp, order stopOrder) {
+func executeStopOrder(app *TradeApp, order stopOrder) {
 	tradeParams := parsedTradeParams{
 		Product:      order.Product,
-		OrderType:    "MARKET",
+		OrderType:    TradeTypeMarket,
 		Side:         order.Side,
 		BaseQuantity: order.BaseQuantity,
 	}
-	app.ConstructTrade(tradeParams, "", app.SessionId)
 
-	if err := app.CancelOrder(order.PlacedOrderId); err != nil {
-		log.Printf("Failed to cancel order with Id %s: %v", order.PlacedOrderId, err)
+	limitPrice := ""
+	if !order.LimitPrice.IsZero() {
+		tradeParams.OrderType = TradeTypeLimit
+		limitPrice = order.LimitPrice.String()
 	}
-}
 
-func executeStopSellOco(app *TradeApp, order stopOrder) {
-	tradeParams := parsedTradeParams{
-		Product:      order.Product,
-		Side:         order.Side,
-		BaseQuantity: order.BaseQuantity,
+	if order.PlacedOrderId != "" {
+		if err := app.CancelOrder(order.PlacedOrderId, order.ClOrdId); err != nil {
+			app.logCancelFailure(order.PlacedOrderId, err)
+			log.Printf(Yellow+"Stop for %s not sent because linked order %s could not be cancelled"+Reset, order.Product, order.PlacedOrderId)
+			return
+		}
 	}
-	app.ConstructTrade(tradeParams, fmt.Sprintf("%.2f", order.StopPrice), app.SessionId)
-
-	if err := app.CancelOrder(order.PlacedOrderId); err != nil {
-		log.Printf("Failed to cancel order with Id %s: %v", order.PlacedOrderId, err)
+	if _, err := app.ConstructTrade(tradeParams, limitPrice, app.SessionId); err != nil {
+		app.logOrderFailure(tradeParams, err)
 	}
 }
 
-func StartPriceFetchingTask(app *TradeApp, products []string, interval time.Duration) {
-	for _, product := range products {
-		getAndCheckPrice(app, product)
-	}
+func StartPriceFetchingTask(ctx context.Context, app *TradeApp, products []string, interval time.Duration) func() {
+	app.fetchAllPrices(ctx, products)
 
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 	ticker := time.NewTicker(interval)
 
 	go func() {
+		defer close(done)
+		defer ticker.Stop()
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case <-ticker.C:
-				for _, product := range products {
-					getAndCheckPrice(app, product)
-				}
+				app.fetchAllPrices(ctx, products)
 			}
 		}
 	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
 }
 
-func (app *TradeApp) validateOrderAgainstFFP(product, side, orderType, limitPrice string, amount float64) bool {
-	priceData, exists := priceCache[product]
+func (app *TradeApp) validateOrderAgainstFFP(params parsedTradeParams, limitPrice string) bool {
+	product, side, orderType := params.Product, params.Side, params.OrderType
+	priceData, exists := app.prices.Get(product)
 	if !exists {
-		fmt.Printf(Yellow+"Warning: Product not added to fat finger protection. Add %s to products in main.go.\n"+Reset, product)
+		fmt.Printf(Yellow+"Warning: Product not added to fat finger protection. Add %s to SupportedProducts in creds.json.\n"+Reset, product)
 		return true
 	}
 
+	if age, maxAge := time.Since(priceData.FetchedAt), app.maxPriceAge(); age > maxAge {
+		fmt.Printf(Red+"Error: Reference price for %s is %s old (max %s). The price feed may be down, order not submitted.\n"+Reset, product, age.Round(time.Second), maxAge)
+		return false
+	}
+
+	buyMultiplier, sellMultiplier, thresholdPct := app.ffpMultipliers(product)
+
 	var maxLimPrice, bestPrice decimal.Decimal
 	var err error
 	switch side {
@@ -163,8 +338,7 @@ func (app *TradeApp) validateOrderAgainstFFP(product, side, orderType, limitPric
 			log.Printf("Error parsing Bid price: %v", err)
 			return false
 		}
-		multiplier := decimal.NewFromFloat(BuyPriceMultiplier)
-		maxLimPrice = bestPrice.Mul(multiplier)
+		maxLimPrice = bestPrice.Mul(buyMultiplier)
 
 	case TradeSideSell:
 		bestPrice, err = decimal.NewFromString(priceData.Ask)
@@ -172,14 +346,28 @@ func (app *TradeApp) validateOrderAgainstFFP(product, side, orderType, limitPric
 			log.Printf("Error parsing Ask price: %v", err)
 			return false
 		}
-		multiplier := decimal.NewFromFloat(SellPriceMultiplier)
-		maxLimPrice = bestPrice.Mul(multiplier)
+		maxLimPrice = bestPrice.Mul(sellMultiplier)
+	}
+
+	var spend decimal.Decimal
+	if params.QuoteQuantity != "" {
+		spend, err = decimal.NewFromString(params.QuoteQuantity)
+		if err != nil || !spend.IsPositive() {
+			fmt.Println("Error: Invalid quote quantity.")
+			return false
+		}
+	} else {
+		amount, err := decimal.NewFromString(params.BaseQuantity)
+		if err != nil {
+			fmt.Println("Error: Invalid order size.")
+			return false
+		}
+		spend = bestPrice.Mul(amount)
 	}
-	amountDecimal := decimal.NewFromFloat(amount)
-	spend := bestPrice.Mul(amountDecimal)
 
-	if spend.GreaterThan(app.MaxOrderSize) {
-		fmt.Println("Error: Order size exceeds the max order size limit.")
+	maxOrderSize, limitName := app.maxOrderSizeFor(product)
+	if spend.GreaterThan(maxOrderSize) {
+		fmt.Printf("Error: Order notional %s exceeds the %s of %s.\n", spend.StringFixed(2), limitName, maxOrderSize.String())
 		return false
 	}
 
@@ -191,10 +379,37 @@ func (app *TradeApp) validateOrderAgainstFFP(product, side, orderType, limitPric
 		}
 
 		if (side == TradeSideBuy && limitPriceDecimal.GreaterThan(maxLimPrice)) || (side == TradeSideSell && limitPriceDecimal.LessThan(maxLimPrice)) {
-			fmt.Println("Error: Order price deviates more than 5% from the best bid/ask.")
+			fmt.Printf("Error: Order price deviates more than %s%% from the best bid/ask.\n", thresholdPct.String())
 			return false
 		}
 	}
 
 	return true
 }
+
+func (app *TradeApp) maxPriceAge() time.Duration {
+	if app.MaxPriceAgeSeconds <= 0 {
+		return app.priceFetchInterval() * priceStaleMultiplier
+	}
+	return time.Duration(app.MaxPriceAgeSeconds) * time.Second
+}
+
+func (app *TradeApp) ffpMultipliers(product string) (decimal.Decimal, decimal.Decimal, decimal.Decimal) {
+	threshold, ok := app.FfpThresholds[product]
+	if !ok || threshold <= 0 {
+		buyMultiplier := decimal.NewFromFloat(BuyPriceMultiplier)
+		return buyMultiplier, decimal.NewFromFloat(SellPriceMultiplier), buyMultiplier.Sub(decimal.NewFromInt(1)).Mul(decimal.NewFromInt(100))
+	}
+
+	pct := decimal.NewFromFloat(threshold)
+	offset := pct.Div(decimal.NewFromInt(100))
+	one := decimal.NewFromInt(1)
+	return one.Add(offset), one.Sub(offset), pct
+}
+
+func (app *TradeApp) maxOrderSizeFor(product string) (decimal.Decimal, string) {
+	if limit, ok := app.ProductMaxOrderSizes[product]; ok {
+		return limit, product + " max order size"
+	}
+	return app.MaxOrderSize, "global max order size"
+}
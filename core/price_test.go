@@ -0,0 +1,111 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestPriceStoreConcurrentAccess is meant to be run with -race: the fetch loop writes the
+// cache while order validation reads it from the input goroutine.
+func TestPriceStoreConcurrentAccess(t *testing.T) {
+	app := newTestApp(t, "")
+	app.MaxOrderSize = MaxOrderSize
+	app.prices.Set("BTC-USD", PriceData{Bid: "100", Ask: "100", FetchedAt: time.Now()})
+
+	params := parsedTradeParams{Product: "BTC-USD", OrderType: TradeTypeLimit, Side: TradeSideBuy, BaseQuantity: "1"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				price := fmt.Sprintf("%d.%02d", 100, (worker+j)%50)
+				app.prices.Set("BTC-USD", PriceData{Bid: price, Ask: price, FetchedAt: time.Now()})
+			}
+		}(i)
+	}
+
+	failures := make(chan string, 8)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, ok := app.prices.Get("BTC-USD"); !ok {
+					failures <- "price missing from cache"
+					return
+				}
+				if !app.validateOrderAgainstFFP(params, "100") {
+					failures <- "order within the band was rejected"
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
+
+func TestFetchAllPricesRunsInParallel(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(delay)
+		w.Write([]byte(`{"bid":"99","ask":"101","price":"100"}`))
+	}))
+	defer server.Close()
+
+	products := []string{"BTC-USD", "ETH-USD", "SOL-USD", "LTC-USD"}
+	app := newTestApp(t, "")
+	app.PriceFeedUrl = server.URL
+	app.priceLimiter = rate.NewLimiter(priceFeedRateLimit, priceFeedRateLimit)
+	app.products = make(map[string]Product)
+	for _, product := range products {
+		app.products[product] = Product{Id: product, QuoteIncrement: "0.01"}
+	}
+
+	start := time.Now()
+	app.fetchAllPrices(app.ctx, products)
+	elapsed := time.Since(start)
+
+	if requests != int32(len(products)) {
+		t.Fatalf("requests = %d, want %d", requests, len(products))
+	}
+	if elapsed >= 2*delay {
+		t.Errorf("fetching %d products took %s, want about one request (%s)", len(products), elapsed, delay)
+	}
+	for _, product := range products {
+		if data, ok := app.prices.Get(product); !ok || data.Price != "100" {
+			t.Errorf("price for %s = %+v, %v", product, data, ok)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/coinbase-samples/trader-shell-go/config"
+	"github.com/shopspring/decimal"
+)
+
+const DefaultDisplayPlaces = 2
+
+var (
+	ErrInvalidProduct = errors.New("invalid product")
+	ErrInvalidAsset   = errors.New("invalid asset")
+)
+
+// normalizeAsset trims and upper-cases an asset symbol such as "eth", rejecting anything that is not alphanumeric.
+func normalizeAsset(asset string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(asset))
+	if normalized == "" {
+		return "", fmt.Errorf("%w: empty asset", ErrInvalidAsset)
+	}
+	for _, r := range normalized {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "", fmt.Errorf("%w %q, expected letters and digits only, e.g. ETH", ErrInvalidAsset, asset)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeProduct canonicalizes a product id such as "eth-usd" to "ETH-USD", requiring the BASE-QUOTE shape.
+func normalizeProduct(product string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(product), "-")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("%w %q, expected format asset1-asset2", ErrInvalidProduct, product)
+	}
+	for i, part := range parts {
+		asset, err := normalizeAsset(part)
+		if err != nil {
+			return "", fmt.Errorf("%w %q, expected format asset1-asset2", ErrInvalidProduct, product)
+		}
+		parts[i] = asset
+	}
+	return parts[0] + "-" + parts[1], nil
+}
+
+type Product struct {
+	Id             string `json:"id"`
+	BaseIncrement  string `json:"base_increment"`
+	QuoteIncrement string `json:"quote_increment"`
+	BaseMinSize    string `json:"base_min_size"`
+	QuoteMinSize   string `json:"quote_min_size"`
+	BaseMaxSize    string `json:"base_max_size"`
+	QuoteMaxSize   string `json:"quote_max_size"`
+}
+
+type ProductsResponse struct {
+	Products   []Product `json:"products"`
+	Pagination struct {
+		NextCursor string `json:"next_cursor"`
+		HasNext    bool   `json:"has_next"`
+	} `json:"pagination"`
+}
+
+func (app *TradeApp) GetProducts() (map[string]Product, error) {
+	app.productsMutex.Lock()
+	defer app.productsMutex.Unlock()
+
+	if app.products != nil {
+		return app.products, nil
+	}
+
+	path := fmt.Sprintf("/v1/portfolios/%s/products", app.PortfolioId)
+	products := make(map[string]Product)
+	cursor := ""
+	for {
+		queryParams := "limit=250"
+		if cursor != "" {
+			queryParams += "&cursor=" + url.QueryEscape(cursor)
+		}
+
+		body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, queryParams, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch products: %w", err)
+		}
+
+		var response ProductsResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+
+		for _, product := range response.Products {
+			products[product.Id] = product
+		}
+
+		if !response.Pagination.HasNext || response.Pagination.NextCursor == "" {
+			break
+		}
+		cursor = response.Pagination.NextCursor
+	}
+
+	app.products = products
+	return products, nil
+}
+
+func (app *TradeApp) validateKnownProduct(product string) error {
+	products, err := app.GetProducts()
+	if err != nil {
+		for _, supported := range app.SupportedProducts {
+			if supported == product {
+				return nil
+			}
+		}
+		return fmt.Errorf("unable to verify product %s: %v", product, err)
+	}
+
+	if _, ok := products[product]; !ok {
+		return fmt.Errorf("unknown product %s", product)
+	}
+	return nil
+}
+
+type productIncrements struct {
+	Base  decimal.Decimal
+	Quote decimal.Decimal
+}
+
+// formatIncrement renders a decimal string with as many places as the product increment, e.g. 0.01 -> 2.
+func formatIncrement(value string, increment decimal.Decimal) string {
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return valueOrX(value)
+	}
+	places, ok := incrementPlaces(increment)
+	if !ok {
+		return parsed.String()
+	}
+	return parsed.StringFixed(places)
+}
+
+func incrementPlaces(increment decimal.Decimal) (int32, bool) {
+	if !increment.IsPositive() {
+		return 0, false
+	}
+	if exponent := increment.Exponent(); exponent < 0 {
+		return -exponent, true
+	}
+	return 0, true
+}
+
+// bookPrecision returns the configured display places for a product, falling back to its tick and lot sizes.
+func (app *TradeApp) bookPrecision(productId string) config.DisplayPrecision {
+	if precision, ok := app.BookPrecision[productId]; ok {
+		return precision
+	}
+
+	precision := config.DisplayPrecision{Price: DefaultDisplayPlaces, Quantity: DefaultDisplayPlaces}
+	increments := app.productIncrements(productId)
+	if places, ok := incrementPlaces(increments.Quote); ok {
+		precision.Price = places
+	}
+	if places, ok := incrementPlaces(increments.Base); ok {
+		precision.Quantity = places
+	}
+	return precision
+}
+
+// orderMinimum combines the configured minimums for a product with the venue's base_min_size and quote_min_size.
+func (app *TradeApp) orderMinimum(productId string) config.OrderMinimum {
+	minimum := app.MinOrderSizes[productId]
+	if minimum.BaseQuantity.IsPositive() && minimum.Notional.IsPositive() {
+		return minimum
+	}
+
+	products, err := app.GetProducts()
+	if err != nil {
+		app.debugf("Unable to load minimum sizes for %s: %v", productId, err)
+		return minimum
+	}
+	product, ok := products[productId]
+	if !ok {
+		return minimum
+	}
+
+	if base, err := decimal.NewFromString(product.BaseMinSize); err == nil && !minimum.BaseQuantity.IsPositive() {
+		minimum.BaseQuantity = base
+	}
+	if quote, err := decimal.NewFromString(product.QuoteMinSize); err == nil && !minimum.Notional.IsPositive() {
+		minimum.Notional = quote
+	}
+	return minimum
+}
+
+func (app *TradeApp) productIncrements(productId string) productIncrements {
+	products, err := app.GetProducts()
+	if err != nil {
+		app.debugf("Unable to load increments for %s: %v", productId, err)
+		return productIncrements{}
+	}
+
+	product, ok := products[productId]
+	if !ok {
+		return productIncrements{}
+	}
+
+	var increments productIncrements
+	if base, err := decimal.NewFromString(product.BaseIncrement); err == nil {
+		increments.Base = base
+	}
+	if quote, err := decimal.NewFromString(product.QuoteIncrement); err == nil {
+		increments.Quote = quote
+	}
+	return increments
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const maxRecordedFrame = 16 * 1024 * 1024
+
+type recordedFrame struct {
+	ReceivedAt time.Time       `json:"received_at"`
+	Message    json.RawMessage `json:"message"`
+}
+
+// feedRecorder appends raw websocket frames to a JSON Lines file. Writes are buffered and
+// flushed on Close, so recording does not slow down the live display.
+type feedRecorder struct {
+	file   *os.File
+	writer *bufio.Writer
+	frames int
+}
+
+func openFeedRecorder(path string) (*feedRecorder, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &feedRecorder{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// Record appends one frame with its receive time. A nil feedRecorder is a no-op.
+func (r *feedRecorder) Record(receivedAt time.Time, frame []byte) {
+	if r == nil {
+		return
+	}
+
+	message := json.RawMessage(frame)
+	if !json.Valid(frame) {
+		quoted, err := json.Marshal(string(frame))
+		if err != nil {
+			return
+		}
+		message = quoted
+	}
+
+	line, err := json.Marshal(recordedFrame{ReceivedAt: receivedAt.UTC(), Message: message})
+	if err != nil {
+		fmt.Printf(Red+"Failed to encode recorded frame: %v\n"+Reset, err)
+		return
+	}
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		fmt.Printf(Red+"Failed to write recording: %v\n"+Reset, err)
+		return
+	}
+	r.frames++
+}
+
+func (r *feedRecorder) Close() {
+	if r == nil {
+		return
+	}
+	if err := r.writer.Flush(); err != nil {
+		fmt.Printf(Red+"Failed to flush recording: %v\n"+Reset, err)
+	}
+	r.file.Close()
+	fmt.Printf("Recorded %d frames to %s\n", r.frames, r.file.Name())
+}
+
+// ReplayOrderBook rebuilds and renders order books from a feed recorded with -rec. With realtime
+// set, frames are paced by their recorded receive times; otherwise they are applied as fast as
+// they can be rendered. The replay builds its own books, so the live books used by risk checks
+// are left untouched.
+func (app *TradeApp) ReplayOrderBook(path string, n int, realtime bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	exitCh := make(chan struct{})
+	var once sync.Once
+	app.setStopStream(func() { once.Do(func() { close(exitCh) }) })
+	defer app.setStopStream(nil)
+
+	books := make(map[string]*OrderBookProcessor)
+	app.FirstPrint = true
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordedFrame)
+
+	var productIds []string
+	var last time.Time
+	frames, line := 0, 0
+	for scanner.Scan() {
+		line++
+		var frame recordedFrame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+
+		if realtime && !last.IsZero() && frame.ReceivedAt.After(last) {
+			select {
+			case <-exitCh:
+				return nil
+			case <-app.ctx.Done():
+				return app.ctx.Err()
+			case <-time.After(frame.ReceivedAt.Sub(last)):
+			}
+		} else {
+			select {
+			case <-exitCh:
+				return nil
+			default:
+			}
+		}
+		last = frame.ReceivedAt
+
+		envelope, err := parseWsEnvelope(string(frame.Message))
+		if err != nil || envelope.Channel != ChannelL2 {
+			continue
+		}
+		if _, seen := books[envelope.ProductId]; !seen && envelope.EventType == L2EventSnapshot {
+			productIds = append(productIds, envelope.ProductId)
+		}
+		if !app.applyL2Message(books, envelope, string(frame.Message)) {
+			continue
+		}
+		frames++
+		displayOrderBooks(app, books, nil, nil, productIds, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Replayed %d order book frames from %s\n", frames, path)
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coinbase-samples/trader-shell-go/config"
+)
+
+func TestReplayOrderBook(t *testing.T) {
+	app := newTestApp(t, "")
+	app.BookPrecision = map[string]config.DisplayPrecision{"BTC-USD": {Price: 2, Quantity: 2}}
+	liveBook := &OrderBookProcessor{}
+	app.OrderBooks = map[string]*OrderBookProcessor{"ETH-USD": liveBook}
+
+	var err error
+	output := captureStdout(t, func() {
+		err = app.ReplayOrderBook(filepath.Join("testdata", "replay.jsonl"), 2, false)
+	})
+	if err != nil {
+		t.Fatalf("ReplayOrderBook returned %v", err)
+	}
+
+	if !strings.Contains(output, "Replayed 2 order book frames") {
+		t.Errorf("expected two replayed frames, got:\n%s", output)
+	}
+	// The last frame has the 100 bid removed and a new best ask at 100.50.
+	last := output[strings.LastIndex(output, "BTC-USD"):]
+	for _, want := range []string{"2.00 @ 100.50", "1.50 @ 101.00", "3.00 @ 99.00"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("final frame missing %q:\n%s", want, last)
+		}
+	}
+	if strings.Contains(last, "@ 100.00") {
+		t.Errorf("final frame still shows the removed 100 bid:\n%s", last)
+	}
+
+	if len(app.OrderBooks) != 1 || app.OrderBooks["ETH-USD"] != liveBook {
+		t.Errorf("replay replaced the live order books: %v", app.OrderBooks)
+	}
+}
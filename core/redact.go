@@ -0,0 +1,104 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+const (
+	RedactedValue = "[REDACTED]"
+	fixDelimiter  = "\x01"
+)
+
+var redactedFixTags = map[int]bool{
+	FixTagPassword:  true,
+	FixTagRawData:   true,
+	FixTagAccessKey: true,
+}
+
+var redactedHeaders = []string{HeaderAccessSig, HeaderAccessKey, HeaderPassphrase}
+
+func redactFix(raw string) string {
+	fields := strings.Split(raw, fixDelimiter)
+	for i, field := range fields {
+		tag, _, found := strings.Cut(field, "=")
+		if !found {
+			continue
+		}
+		if number, err := strconv.Atoi(tag); err == nil && redactedFixTags[number] {
+			fields[i] = tag + "=" + RedactedValue
+		}
+	}
+	return strings.Join(fields, fixDelimiter)
+}
+
+func fixString(message *quickfix.Message) string {
+	return redactFix(message.String())
+}
+
+func redactHeaders(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for key, value := range headers {
+		redacted[key] = value
+	}
+	for _, key := range redactedHeaders {
+		if _, ok := redacted[key]; ok {
+			redacted[key] = RedactedValue
+		}
+	}
+	return redacted
+}
+
+// redactingLogger masks the configured secrets in every message and string field before passing it on.
+type redactingLogger struct {
+	next     Logger
+	replacer *strings.Replacer
+}
+
+func newRedactingLogger(next Logger, secrets ...string) Logger {
+	var pairs []string
+	for _, secret := range secrets {
+		if strings.TrimSpace(secret) != "" {
+			pairs = append(pairs, secret, RedactedValue)
+		}
+	}
+	if len(pairs) == 0 {
+		return next
+	}
+	return &redactingLogger{next: next, replacer: strings.NewReplacer(pairs...)}
+}
+
+func (l *redactingLogger) Log(level, event, message string, fields LogFields) {
+	var redacted LogFields
+	if fields != nil {
+		redacted = make(LogFields, len(fields))
+		for key, value := range fields {
+			switch v := value.(type) {
+			case string:
+				value = l.replacer.Replace(v)
+			case error:
+				value = l.replacer.Replace(v.Error())
+			}
+			redacted[key] = value
+		}
+	}
+	l.next.Log(level, event, l.replacer.Replace(message), redacted)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quickfixgo/quickfix"
+)
+
+const (
+	testSecret     = "c2VjcmV0LXZhbHVl"
+	testPassphrase = "hunter2-passphrase"
+	testAccessKey  = "access-key-1234"
+	testSignature  = "c2lnbmF0dXJl"
+)
+
+func assertNoSecrets(t *testing.T, output string, secrets ...string) {
+	t.Helper()
+	for _, secret := range secrets {
+		if strings.Contains(output, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, output)
+		}
+	}
+	if !strings.Contains(output, RedactedValue) {
+		t.Errorf("output does not contain %s:\n%s", RedactedValue, output)
+	}
+}
+
+func TestRedactingLoggerMasksSecrets(t *testing.T) {
+	var out bytes.Buffer
+	logger := newRedactingLogger(&jsonLogger{out: &out, debug: true}, testSecret, testPassphrase, testAccessKey)
+
+	logger.Log(LogLevelInfo, "test", "secret is "+testSecret, LogFields{
+		"passphrase": testPassphrase,
+		"error":      errors.New("bad key " + testAccessKey),
+		"count":      3,
+	})
+
+	assertNoSecrets(t, out.String(), testSecret, testPassphrase, testAccessKey)
+}
+
+func TestRedactFix(t *testing.T) {
+	raw := strings.Join([]string{
+		"8=FIX.4.2", "35=A", "96=" + testSignature, "554=" + testPassphrase, "9407=" + testAccessKey, "10=000",
+	}, fixDelimiter) + fixDelimiter
+
+	redacted := redactFix(raw)
+	assertNoSecrets(t, redacted, testSignature, testPassphrase, testAccessKey)
+	if !strings.Contains(redacted, "35=A"+fixDelimiter) {
+		t.Errorf("redactFix dropped non-secret fields: %q", redacted)
+	}
+}
+
+func TestFixStringRedactsLogon(t *testing.T) {
+	message := quickfix.NewMessage()
+	message.Header.SetString(quickfix.Tag(FixTagMsgType), "A")
+	message.Body.SetString(quickfix.Tag(FixTagRawData), testSignature)
+	message.Body.SetString(quickfix.Tag(FixTagPassword), testPassphrase)
+	message.Body.SetString(quickfix.Tag(FixTagAccessKey), testAccessKey)
+
+	assertNoSecrets(t, fixString(message), testSignature, testPassphrase, testAccessKey)
+}
@@ -19,6 +19,7 @@ package core
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -27,14 +28,21 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/coinbase-samples/trader-shell-go/config"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -43,9 +51,85 @@ const (
 	HeaderAccessTime = "X-CB-ACCESS-TIMESTAMP"
 	HeaderAccessKey  = "X-CB-ACCESS-KEY"
 	HeaderPassphrase = "X-CB-ACCESS-PASSPHRASE"
+
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultMaxRetries     = 3
+	DefaultOrdersPageSize = 20
+	DefaultOrdersRefresh  = 5 * time.Second
+	DefaultBalanceCache   = 5 * time.Second
+	DisplayTimeFormat     = "2006-01-02 15:04:05"
+	RetryBaseDelay        = 500 * time.Millisecond
+	DefaultRestRateLimit  = 10
+	DefaultRestBurst      = 5
+	CancelConfirmTimeout  = 3 * time.Second
+	CancelPollInterval    = 500 * time.Millisecond
+
+	OrderStatusCancelled = "CANCELLED"
+	OrderStatusFilled    = "FILLED"
+	OrderStatusExpired   = "EXPIRED"
+	OrderStatusFailed    = "FAILED"
+)
+
+var (
+	ErrOrderCanceled  = errors.New("order Canceled")
+	ErrOrderModified  = errors.New("order Modified")
+	ErrCancelRejected = errors.New("cancel rejected")
+	ErrNextPage       = errors.New("next page requested")
 )
 
-var ErrOrderCanceled = errors.New("order Canceled")
+var (
+	ErrUnauthorized = errors.New("request unauthorized")
+	ErrRateLimited  = errors.New("request rate limited")
+	ErrNotFound     = errors.New("resource not found")
+	ErrVenueReject  = errors.New("request rejected by venue")
+	ErrServerError  = errors.New("venue server error")
+	// ErrRequestTimeout is a single attempt running out of time while the caller is still waiting.
+	ErrRequestTimeout = errors.New("request timed out")
+)
+
+// httpStatusError is returned for any non-2xx response. It unwraps to one of the sentinels above
+// by status code, and Message carries the server's explanation when it sent one.
+type httpStatusError struct {
+	StatusCode int
+	Body       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("coinbase returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("coinbase returned %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), strings.TrimSpace(e.Body))
+}
+
+func (e *httpStatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	case e.StatusCode >= http.StatusBadRequest:
+		return ErrVenueReject
+	}
+	return nil
+}
+
+func newHttpStatusError(statusCode int, body []byte) *httpStatusError {
+	statusErr := &httpStatusError{StatusCode: statusCode, Body: string(body)}
+
+	var envelope struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		statusErr.Message = envelope.Message
+	}
+	return statusErr
+}
 
 type OrderPreviewResponse struct {
 	BaseQuantity       string `json:"base_quantity"`
@@ -60,28 +144,83 @@ type OrderPreviewResponse struct {
 }
 
 type Balance struct {
+	Symbol             string `json:"symbol"`
 	Amount             string `json:"amount"`
 	Holds              string `json:"holds"`
 	WithdrawableAmount string `json:"withdrawable_amount"`
 	FiatAmount         string `json:"fiat_amount"`
 }
 
+type Fill struct {
+	Id             string `json:"id"`
+	OrderId        string `json:"order_id"`
+	ProductId      string `json:"product_id"`
+	Side           string `json:"side"`
+	FilledQuantity string `json:"filled_quantity"`
+	FilledValue    string `json:"filled_value"`
+	Price          string `json:"price"`
+	Commission     string `json:"commission"`
+	Time           string `json:"time"`
+	Venue          string `json:"venue"`
+}
+
+type FillsResponse struct {
+	Fills []Fill `json:"fills"`
+}
+
 type BalanceResponse struct {
 	Balances []Balance `json:"balances"`
 }
 
-func (app *TradeApp) makeAuthenticatedRequest(method, path, queryParams string, body []byte) ([]byte, error) {
-	uri := BaseURL + path
+func (app *TradeApp) makeAuthenticatedRequest(ctx context.Context, method, path, queryParams string, body []byte) ([]byte, error) {
+	retries := 0
+	if method == http.MethodGet || app.RetryPostRequests {
+		retries = app.maxRetries()
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := app.sendAuthenticatedRequest(ctx, method, path, queryParams, body)
+		if err == nil || !isRetryableError(err) {
+			return response, err
+		}
+
+		rateLimited := errors.Is(err, ErrRateLimited)
+		if attempt >= retries && !(rateLimited && attempt < app.maxRetries()) {
+			return response, err
+		}
+
+		delay := retryDelay(attempt, err)
+		log.Printf(Yellow+"%s %s failed: %v. Retrying in %s (%d/%d)..."+Reset, method, path, err, delay, attempt+1, retries)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay is the backoff before retrying attempt, or the venue's Retry-After when it rate limited us.
+func retryDelay(attempt int, err error) time.Duration {
+	var statusErr *httpStatusError
+	if errors.Is(err, ErrRateLimited) && errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter
+	}
+	return retryBackoff(attempt)
+}
+
+func (app *TradeApp) sendAuthenticatedRequest(ctx context.Context, method, path, queryParams string, body []byte) ([]byte, error) {
+	uri := app.restBaseUrl() + path
 	if queryParams != "" {
 		uri += "?" + queryParams
 	}
 
-	timestamp := strconv.Itoa(int(time.Now().Unix()))
-	message := timestamp + method + path
-	if body != nil {
-		message += string(body)
+	// Wait before signing so a long queue behind the limiter cannot push the timestamp out of the venue's window.
+	if err := app.restLimiter.Wait(ctx); err != nil {
+		return nil, err
 	}
-	signature := computeHMAC256(message, app.ApiSecret)
+
+	timestamp := strconv.Itoa(int(time.Now().Unix()))
+	signature := computeHMAC256(restSignatureMessage(timestamp, method, path, body), app.ApiSecret)
 
 	headers := map[string]string{
 		HeaderAccessSig:  signature,
@@ -91,61 +230,297 @@ func (app *TradeApp) makeAuthenticatedRequest(method, path, queryParams string,
 		"Accept":         "application/json",
 	}
 
-	return makeRequest(method, uri, body, headers)
+	timeout := app.requestTimeout()
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := makeRequest(attemptCtx, app.httpClient, method, uri, body, headers, app.logger)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		// Only this attempt's deadline expired, so report a retryable timeout rather than the
+		// context error that would otherwise stop the retries.
+		return nil, fmt.Errorf("%w: %s %s after %s: %v", ErrRequestTimeout, method, path, timeout, err)
+	}
+	return response, err
 }
 
-func (app *TradeApp) extractOrdersFromResponse(body []byte) ([]interface{}, error) {
+func (app *TradeApp) restBaseUrl() string {
+	if app.RestBaseUrl == "" {
+		return BaseURL
+	}
+	return strings.TrimRight(app.RestBaseUrl, "/")
+}
+
+func newRestLimiter(credentials *config.Config) *rate.Limiter {
+	limit, burst := credentials.RestRateLimit, credentials.RestBurst
+	if limit <= 0 {
+		limit = DefaultRestRateLimit
+	}
+	if burst <= 0 {
+		burst = DefaultRestBurst
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+func (app *TradeApp) maxRetries() int {
+	if app.MaxRetries <= 0 {
+		return DefaultMaxRetries
+	}
+	return app.MaxRetries
+}
+
+func retryBackoff(attempt int) time.Duration {
+	return RetryBaseDelay * time.Duration(1<<attempt)
+}
+
+// isRetryableError reports whether a failed request may succeed if sent again. Cancellation,
+// the caller's deadline, TLS and DNS failures are permanent; only per-attempt and network
+// timeouts, refused or reset connections, rate limits and 5xx responses are retried.
+func isRetryableError(err error) bool {
+	if errors.Is(err, ErrRequestTimeout) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServerError) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (app *TradeApp) requestTimeout() time.Duration {
+	if app.RequestTimeoutSeconds <= 0 {
+		return DefaultRequestTimeout
+	}
+	return time.Duration(app.RequestTimeoutSeconds) * time.Second
+}
+
+func (app *TradeApp) extractOrdersFromResponse(body []byte) ([]interface{}, string, error) {
 	var parsedResponse map[string]interface{}
 	if err := json.Unmarshal(body, &parsedResponse); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	orders, ok := parsedResponse["orders"].([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("failed to extract orders from response")
+		return nil, "", fmt.Errorf("failed to extract orders from response")
 	}
 
-	return orders, nil
+	nextCursor := ""
+	if pagination, ok := parsedResponse["pagination"].(map[string]interface{}); ok {
+		hasNext, _ := pagination["has_next"].(bool)
+		cursor, _ := pagination["next_cursor"].(string)
+		if hasNext {
+			nextCursor = cursor
+		}
+	}
+
+	return orders, nextCursor, nil
+}
+
+type orderFilter struct {
+	Product string
+	Side    string
+	Refresh bool
+}
+
+func parseOrderFilter(input string) (orderFilter, error) {
+	var filter orderFilter
+	for _, token := range strings.Fields(input) {
+		switch strings.ToLower(token) {
+		case ArgBuy:
+			filter.Side = TradeSideBuy
+		case ArgSell:
+			filter.Side = TradeSideSell
+		case ArgRefresh:
+			filter.Refresh = true
+		default:
+			product, err := normalizeProduct(token)
+			if err != nil {
+				return orderFilter{}, fmt.Errorf("invalid filter %q, expected a product and/or side, e.g. 'eth-usd b': %w", token, err)
+			}
+			filter.Product = product
+		}
+	}
+	return filter, nil
+}
+
+func (f orderFilter) apply(orders []interface{}) []interface{} {
+	if f.Product == "" && f.Side == "" {
+		return orders
+	}
+
+	var filtered []interface{}
+	for _, order := range orders {
+		orderMap, ok := order.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if f.Product != "" && stringField(orderMap, "product_id") != f.Product {
+			continue
+		}
+		if f.Side != "" && stringField(orderMap, "side") != f.Side {
+			continue
+		}
+		filtered = append(filtered, order)
+	}
+	return filtered
 }
 
-func (app *TradeApp) GetOpenOrders() error {
+func (app *TradeApp) fetchOpenOrders(ctx context.Context) ([]interface{}, error) {
 	path := fmt.Sprintf("/v1/portfolios/%s/open_orders", app.PortfolioId)
-	body, err := app.makeAuthenticatedRequest("GET", path, "", nil)
+	body, err := app.makeAuthenticatedRequest(ctx, "GET", path, "", nil)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to fetch open orders: %w", err)
 	}
 
-	orders, err := app.extractOrdersFromResponse(body)
+	orders, _, err := app.extractOrdersFromResponse(body)
+	return orders, err
+}
+
+func (app *TradeApp) GetOpenOrders(filter orderFilter, reader *bufio.Reader) error {
+	orders, err := app.fetchOpenOrders(app.ctx)
 	if err != nil {
 		return err
 	}
+	if app.jsonOutput() {
+		return writeJson(filter.apply(orders))
+	}
 
-	if err := app.displayAndSelectOrder(orders, false); err != nil {
-		if err == ErrOrderCanceled {
-			return app.GetOpenOrders()
+	if err := app.displayAndSelectOrder(filter.apply(orders), false, false, reader); err != nil {
+		if errors.Is(err, ErrOrderCanceled) || errors.Is(err, ErrOrderModified) {
+			return app.GetOpenOrders(filter, reader)
 		}
 		return err
 	}
 	return nil
 }
 
-func (app *TradeApp) GetAllOrders() error {
+type openOrdersResult struct {
+	orders []interface{}
+	err    error
+}
+
+func (app *TradeApp) ordersRefreshInterval() time.Duration {
+	if app.OrdersRefreshSeconds <= 0 {
+		return DefaultOrdersRefresh
+	}
+	return time.Duration(app.OrdersRefreshSeconds) * time.Second
+}
+
+func (app *TradeApp) WatchOpenOrders(filter orderFilter, reader *bufio.Reader) error {
+	ctx, cancel := context.WithCancel(app.ctx)
+	defer cancel()
+
+	exitCh := make(chan struct{})
+	go readExitInput(reader, exitCh)
+
+	results := make(chan openOrdersResult, 1)
+	inFlight := false
+	poll := func() {
+		inFlight = true
+		go func() {
+			orders, err := app.fetchOpenOrders(ctx)
+			results <- openOrdersResult{orders: orders, err: err}
+		}()
+	}
+
+	interval := app.ordersRefreshInterval()
+	fmt.Printf("Refreshing open orders every %s. Type '%s' to stop.\n", interval, SelectExit)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lines := 0
+	poll()
+	for {
+		select {
+		case <-exitCh:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !inFlight {
+				poll()
+			}
+		case result := <-results:
+			inFlight = false
+			if lines > 0 {
+				fmt.Printf("\033[%dA\033[J", lines)
+			}
+			if result.err != nil {
+				fmt.Printf(Red+"Error: %v\n"+Reset, result.err)
+				lines = 1
+				continue
+			}
+			lines = app.printOrdersTable(filter.apply(result.orders), false) + 1
+			fmt.Printf("Last updated %s\n", time.Now().Format("15:04:05"))
+		}
+	}
+}
+
+func readExitInput(reader *bufio.Reader, exitCh chan struct{}) {
+	for {
+		input, err := reader.ReadString('\n')
+		if err != nil || strings.TrimSpace(input) == SelectExit {
+			close(exitCh)
+			return
+		}
+	}
+}
+
+func (app *TradeApp) GetAllOrders(filter orderFilter, reader *bufio.Reader) error {
+	cursor := ""
+	for {
+		orders, nextCursor, err := app.fetchOrdersPage(cursor)
+		if err != nil {
+			return err
+		}
+		if app.jsonOutput() {
+			return writeJson(filter.apply(orders))
+		}
+
+		// A filter can leave a page empty while later pages still match, so skip ahead instead of stopping.
+		filtered := filter.apply(orders)
+		if len(filtered) == 0 && nextCursor != "" {
+			cursor = nextCursor
+			continue
+		}
+
+		if err := app.displayAndSelectOrder(filtered, true, nextCursor != "", reader); !errors.Is(err, ErrNextPage) {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
+func (app *TradeApp) fetchOrdersPage(cursor string) ([]interface{}, string, error) {
 	path := fmt.Sprintf("/v1/portfolios/%s/orders", app.PortfolioId)
-	body, err := app.makeAuthenticatedRequest("GET", path, "", nil)
-	if err != nil {
-		return err
+	queryParams := fmt.Sprintf("limit=%d", app.ordersPageSize())
+	if cursor != "" {
+		queryParams += "&cursor=" + url.QueryEscape(cursor)
 	}
 
-	orders, err := app.extractOrdersFromResponse(body)
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, queryParams, nil)
 	if err != nil {
-		return err
+		return nil, "", fmt.Errorf("failed to fetch orders: %w", err)
 	}
 
-	app.displayAndSelectOrder(orders, true)
-	return nil
+	return app.extractOrdersFromResponse(body)
+}
+
+func (app *TradeApp) ordersPageSize() int {
+	if app.OrdersPageSize <= 0 {
+		return DefaultOrdersPageSize
+	}
+	return app.OrdersPageSize
 }
 
-func (app *TradeApp) displayAndSelectOrder(orders []interface{}, allOrders bool) error {
+func (app *TradeApp) displayAndSelectOrder(orders []interface{}, allOrders, hasNextPage bool, reader *bufio.Reader) error {
 	for {
 		if len(orders) == 0 {
 			if allOrders {
@@ -153,49 +528,40 @@ func (app *TradeApp) displayAndSelectOrder(orders []interface{}, allOrders bool)
 			} else {
 				fmt.Println("No open orders found!")
 			}
-			return fmt.Errorf("no orders found")
-		}
-
-		if allOrders && len(orders) > 20 {
-			orders = orders[:20]
+			return nil
 		}
 
-		fmt.Println(Blue + "#  | Id                                   | Product | Side | Type   | Lim Px  | Base Qty| Quote Val" + Reset)
-		for i, order := range orders {
-			orderMap, ok := order.(map[string]interface{})
-			if !ok {
-				log.Println("Order is not a valid map")
-				return fmt.Errorf("invalid order map")
-			}
-
-			id := valueOrX(orderMap["id"].(string))
-			product := valueOrX(orderMap["product_id"].(string))
-			side := valueOrX(orderMap["side"].(string))
-			orderType := valueOrX(orderMap["type"].(string))
-			limitPrice := valueOrX(orderMap["limit_price"].(string))
-			baseQuantity := valueOrX(orderMap["base_quantity"].(string))
-			quoteValue := valueOrX(orderMap["quote_value"].(string))
-
-			fmt.Printf(Blue+"%-3d| %-37s| %-8s| %-5s| %-7s| %-8s| %-8s| %s\n"+Reset, i+1, id, product, side, orderType, limitPrice, baseQuantity, quoteValue)
-
-		}
+		app.printOrdersTable(orders, allOrders)
 
 		if allOrders {
-			fmt.Print("Type 'x' to return to previous menu: ")
-			reader := bufio.NewReader(os.Stdin)
+			if hasNextPage {
+				fmt.Printf("Select an order by number to view its fills, type '%s' for the next page or 'x' to return to previous menu: ", SelectNextPage)
+			} else {
+				fmt.Print("Select an order by number to view its fills or type 'x' to return to previous menu: ")
+			}
 			input, _ := reader.ReadString('\n')
 			input = strings.TrimSpace(input)
 
 			if input == SelectExit {
 				return nil
 			}
+			if hasNextPage && input == SelectNextPage {
+				return ErrNextPage
+			}
+			if choice, err := strconv.Atoi(input); err == nil && choice > 0 && choice <= len(orders) {
+				if orderMap, ok := orders[choice-1].(map[string]interface{}); ok {
+					if err := app.PrintFills(stringField(orderMap, "id")); err != nil {
+						fmt.Println("Error:", err)
+					}
+				}
+				continue
+			}
 
 			fmt.Println("Invalid choice, please type 'x' to return to previous menu.")
 			continue
 		}
 
 		fmt.Print("\nSelect an order by number, add '-c' to cancel, or type 'x' to return to previous menu: ")
-		reader := bufio.NewReader(os.Stdin)
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
@@ -226,13 +592,69 @@ func (app *TradeApp) displayAndSelectOrder(orders []interface{}, allOrders bool)
 			}
 
 			fmt.Println(string(orderJson))
-		} else {
-			if err := app.userActionOnOpenOrder(selectedOrder, orders, autoCancel); err != nil {
-				return ErrOrderCanceled
+		}
+
+		if err := app.userActionOnOpenOrder(selectedOrder, orders, autoCancel, reader); err != nil {
+			if errors.Is(err, ErrOrderModified) || errors.Is(err, ErrOrderCanceled) {
+				return err
 			}
+			fmt.Printf(Red+"Error: %v\n"+Reset, err)
 		}
 	}
-	return nil
+}
+
+func (app *TradeApp) printOrdersTable(orders []interface{}, allOrders bool) int {
+	if len(orders) == 0 {
+		if allOrders {
+			fmt.Println("No orders found!")
+		} else {
+			fmt.Println("No open orders found!")
+		}
+		return 1
+	}
+
+	fmt.Printf(Blue+"%-3s| %-37s| %-8s| %-5s| %-7s| %-20s| %-20s| %12s | %14s | %12s\n"+Reset, "#", "Id", "Product", "Side", "Type", "Created", "Updated", "Lim Px", "Base Qty", "Quote Val")
+	for i, order := range orders {
+		orderMap, ok := order.(map[string]interface{})
+		if !ok {
+			orderMap = map[string]interface{}{}
+		}
+
+		productId := stringField(orderMap, "product_id")
+		increments := app.productIncrements(productId)
+
+		id := valueOrX(stringField(orderMap, "id"))
+		product := valueOrX(productId)
+		side := valueOrX(stringField(orderMap, "side"))
+		orderType := valueOrX(stringField(orderMap, "type"))
+		created := app.formatTimestamp(stringField(orderMap, "created_at"))
+		updated := app.formatTimestamp(stringField(orderMap, "updated_at"))
+		limitPrice := formatIncrement(stringField(orderMap, "limit_price"), increments.Quote)
+		baseQuantity := formatIncrement(stringField(orderMap, "base_quantity"), increments.Base)
+		quoteValue := formatIncrement(stringField(orderMap, "quote_value"), increments.Quote)
+
+		fmt.Printf(Blue+"%-3d| %-37s| %-8s| %-5s| %-7s| %-20s| %-20s| %12s | %14s | %12s\n"+Reset, i+1, id, product, side, orderType, created, updated, limitPrice, baseQuantity, quoteValue)
+	}
+	return len(orders) + 1
+}
+
+func (app *TradeApp) formatTimestamp(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return valueOrX(value)
+	}
+	if app.DisplayUtc {
+		return parsed.UTC().Format(DisplayTimeFormat) + "Z"
+	}
+	return parsed.Local().Format(DisplayTimeFormat)
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	value, ok := m[key].(string)
+	if !ok {
+		return ""
+	}
+	return value
 }
 
 func valueOrX(s string) string {
@@ -242,28 +664,22 @@ func valueOrX(s string) string {
 	return s
 }
 
-func (app *TradeApp) userActionOnOpenOrder(order interface{}, orders []interface{}, autoCancel bool) error {
+func (app *TradeApp) userActionOnOpenOrder(order interface{}, orders []interface{}, autoCancel bool, reader *bufio.Reader) error {
 	if autoCancel {
 		orderMap, ok := order.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid order map")
 		}
 
-		id, ok := orderMap["id"].(string)
-		if !ok {
+		id := stringField(orderMap, "id")
+		if id == "" {
 			return fmt.Errorf("invalid order Id")
 		}
-		if err := app.CancelOrder(id); err != nil {
-			log.Println("Failed to cancel order:", err)
-			return err
-		}
-		time.Sleep(time.Second * 1)
-		return fmt.Errorf("order Canceled")
+		return app.cancelAndConfirm(id, stringField(orderMap, "client_order_id"))
 	}
 
-	reader := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Println("\nType 'c' to cancel the order or type 'x' to go back to the order Id selector.")
+		fmt.Println("\nType 'c' to cancel the order, 'm' to modify it, 'f' to view its fills, or type 'x' to go back to the order Id selector.")
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
@@ -274,16 +690,34 @@ func (app *TradeApp) userActionOnOpenOrder(order interface{}, orders []interface
 				return fmt.Errorf("invalid order map")
 			}
 
-			id, ok := orderMap["id"].(string)
-			if !ok {
+			id := stringField(orderMap, "id")
+			if id == "" {
 				return fmt.Errorf("invalid order Id")
 			}
-			if err := app.CancelOrder(id); err != nil {
-				log.Println("Failed to cancel order:", err)
-				return err
+			return app.cancelAndConfirm(id, stringField(orderMap, "client_order_id"))
+
+		case SelectModify:
+			orderMap, ok := order.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid order map")
+			}
+
+			if err := app.modifyOpenOrder(orderMap, reader); err != nil {
+				fmt.Println("Failed to modify order:", err)
+				continue
 			}
 			time.Sleep(time.Second * 1)
-			return fmt.Errorf("order Canceled")
+			return ErrOrderModified
+
+		case SelectFills:
+			orderMap, ok := order.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid order map")
+			}
+
+			if err := app.PrintFills(stringField(orderMap, "id")); err != nil {
+				fmt.Println("Error:", err)
+			}
 
 		case SelectExit:
 			return nil
@@ -291,27 +725,248 @@ func (app *TradeApp) userActionOnOpenOrder(order interface{}, orders []interface
 			fmt.Println("Invalid choice. Please select again.")
 		}
 	}
+}
+
+func (app *TradeApp) modifyOpenOrder(orderMap map[string]interface{}, reader *bufio.Reader) error {
+	params := replaceOrderParams{}
+	params.OrderId = stringField(orderMap, "id")
+	params.OrigClOrdId = stringField(orderMap, "client_order_id")
+	params.Product = stringField(orderMap, "product_id")
+	params.Side = stringField(orderMap, "side")
+	params.OrderType = stringField(orderMap, "type")
+	params.LimitPrice = stringField(orderMap, "limit_price")
+	params.BaseQuantity = stringField(orderMap, "base_quantity")
+
+	if params.OrderId == "" || params.OrigClOrdId == "" {
+		return fmt.Errorf("order is missing its order Id or client order Id")
+	}
+	if params.OrderType != TradeTypeLimit {
+		return fmt.Errorf("only limit orders can be modified")
+	}
+
+	fmt.Printf("Enter new limit price and base quantity (current: %s %s), use '-' to keep a value:\n", params.LimitPrice, params.BaseQuantity)
+	input, err := GetUserInput(reader)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Fields(input)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected a limit price and base quantity, e.g. '1450 -'")
+	}
+	if parts[0] != "-" {
+		if _, err := decimal.NewFromString(parts[0]); err != nil {
+			return fmt.Errorf("invalid limit price: %s", parts[0])
+		}
+		params.LimitPrice = parts[0]
+	}
+	if parts[1] != "-" {
+		if _, err := decimal.NewFromString(parts[1]); err != nil {
+			return fmt.Errorf("invalid base quantity: %s", parts[1])
+		}
+		params.BaseQuantity = parts[1]
+	}
+
+	clOrdId, err := app.ConstructReplace(params, app.SessionId)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(Blue+"Submitted modify request %s for order %s: %s @ %s\n"+Reset, clOrdId, params.OrderId, params.BaseQuantity, params.LimitPrice)
 	return nil
 }
 
-func (app *TradeApp) CancelOrder(orderId string) error {
+// CancelOrder uses the order's client order Id as its idempotency key: after a failed or timed out
+// request the order is looked up by Id and checked against the key before the cancel is retried.
+func (app *TradeApp) CancelOrder(orderId, clientOrderId string) error {
 	path := fmt.Sprintf("/v1/portfolios/%s/orders/%s/cancel", app.PortfolioId, orderId)
 	payload := map[string]string{
 		"portfolio_id": app.PortfolioId,
 		"order_id":     orderId,
 	}
+	if clientOrderId != "" {
+		payload["client_order_id"] = clientOrderId
+	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	_, err = app.makeAuthenticatedRequest("POST", path, "", payloadBytes)
-	return err
+	// Each attempt is sent once and reconciled against the order's status, so this loop is the
+	// only retry layer for cancels rather than wrapping makeAuthenticatedRequest's own retries.
+	app.balances.Invalidate()
+	for attempt := 0; ; attempt++ {
+		body, err := app.sendAuthenticatedRequest(app.ctx, "POST", path, "", payloadBytes)
+		if err == nil {
+			err = checkCancelResponse(orderId, body)
+		}
+		if err != nil {
+			err = app.reconcileCancel(orderId, clientOrderId, err)
+		}
+		if err == nil {
+			break
+		}
+
+		if !isRetryableError(err) || attempt >= app.maxRetries() {
+			app.audit.Record("order_cancel_failed", LogFields{"order_id": orderId, "client_order_id": clientOrderId, "rest": string(payloadBytes), "error": err})
+			return err
+		}
+		delay := retryDelay(attempt, err)
+		log.Printf(Yellow+"Cancel for order %s failed: %v. Order is still open, retrying in %s (%d/%d)..."+Reset, orderId, err, delay, attempt+1, app.maxRetries())
+		select {
+		case <-app.ctx.Done():
+			return app.ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	app.audit.Record("order_cancel_requested", LogFields{"order_id": orderId, "client_order_id": clientOrderId, "rest": string(payloadBytes)})
+
+	promMetrics.orderCanceled()
+	app.logger.Log(LogLevelInfo, "order_cancel_requested", fmt.Sprintf("Cancel requested for order %s", orderId), LogFields{"order_id": orderId, "client_order_id": clientOrderId})
+	return nil
+}
+
+func (app *TradeApp) reconcileCancel(orderId, clientOrderId string, cause error) error {
+	status, err := app.orderStatus(orderId, clientOrderId)
+	if err != nil {
+		return cause
+	}
+
+	switch status {
+	case OrderStatusCancelled:
+		log.Printf(Yellow+"Cancel for order %s reported %v, but the order is already cancelled\n"+Reset, orderId, cause)
+		return nil
+	case OrderStatusFilled, OrderStatusExpired, OrderStatusFailed:
+		return fmt.Errorf("%w for order %s: order is %s", ErrCancelRejected, orderId, status)
+	}
+
+	if errors.Is(cause, ErrVenueReject) || errors.Is(cause, ErrNotFound) || errors.Is(cause, ErrUnauthorized) {
+		return fmt.Errorf("%w for order %s: %v", ErrCancelRejected, orderId, cause)
+	}
+	return cause
+}
+
+func (app *TradeApp) orderStatus(orderId, clientOrderId string) (string, error) {
+	order, err := app.GetOrder(orderId)
+	if err != nil {
+		return "", err
+	}
+	if key := stringField(order, "client_order_id"); clientOrderId != "" && key != "" && key != clientOrderId {
+		return "", fmt.Errorf("order %s has client order Id %s, expected %s", orderId, key, clientOrderId)
+	}
+	return stringField(order, "status"), nil
 }
 
-func (app *TradeApp) ViewPortfolioBalances() error {
-	reader := bufio.NewReader(os.Stdin)
+func checkCancelResponse(orderId string, body []byte) error {
+	var response struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("%w for order %s: unreadable response: %v", ErrCancelRejected, orderId, err)
+	}
+	if response.Id != orderId {
+		return fmt.Errorf("%w for order %s: response acknowledged %q", ErrCancelRejected, orderId, response.Id)
+	}
+	return nil
+}
+
+func (app *TradeApp) cancelAndConfirm(orderId, clientOrderId string) error {
+	if err := app.CancelOrder(orderId, clientOrderId); err != nil {
+		app.logCancelFailure(orderId, err)
+		return err
+	}
+	if err := app.confirmCancel(orderId, clientOrderId); err != nil {
+		app.logCancelFailure(orderId, err)
+		return err
+	}
+	fmt.Printf(Green+"Order %s canceled\n"+Reset, orderId)
+	return ErrOrderCanceled
+}
+
+func (app *TradeApp) confirmCancel(orderId, clientOrderId string) error {
+	deadline := time.Now().Add(CancelConfirmTimeout)
+	for {
+		status, err := app.orderStatus(orderId, clientOrderId)
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case OrderStatusCancelled:
+			return nil
+		case OrderStatusFilled, OrderStatusExpired, OrderStatusFailed:
+			return fmt.Errorf("%w for order %s: order is %s", ErrCancelRejected, orderId, status)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("cancel for order %s not confirmed within %s, order is still %s", orderId, CancelConfirmTimeout, valueOrX(status))
+		}
+		select {
+		case <-app.ctx.Done():
+			return app.ctx.Err()
+		case <-time.After(CancelPollInterval):
+		}
+	}
+}
+
+func (app *TradeApp) logCancelFailure(orderId string, err error) {
+	app.logger.Log(LogLevelError, "order_cancel_failed", fmt.Sprintf("Failed to cancel order %s: %v", orderId, err), LogFields{
+		"order_id": orderId,
+		"error":    err,
+	})
+}
+
+func (app *TradeApp) CancelAllOrders() error {
+	app.clearStopOrders()
+	if canceled := app.CancelTwaps(); canceled > 0 {
+		fmt.Printf(Yellow+"Canceled %d TWAP schedule(s)\n"+Reset, canceled)
+	}
+
+	path := fmt.Sprintf("/v1/portfolios/%s/open_orders", app.PortfolioId)
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch open orders: %w", err)
+	}
+
+	orders, _, err := app.extractOrdersFromResponse(body)
+	if err != nil {
+		return err
+	}
+
+	succeeded, failed := 0, 0
+	for _, order := range orders {
+		orderMap, ok := order.(map[string]interface{})
+		if !ok {
+			failed++
+			continue
+		}
+
+		id := stringField(orderMap, "id")
+		if err := app.CancelOrder(id, stringField(orderMap, "client_order_id")); err != nil {
+			app.logCancelFailure(id, err)
+			failed++
+			continue
+		}
+		succeeded++
+	}
+
+	fmt.Printf(Yellow+"Cancel all: %d canceled, %d failed\n"+Reset, succeeded, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cancels failed", failed, len(orders))
+	}
+	return nil
+}
+
+func (app *TradeApp) clearStopOrders() {
+	app.stopOrdersMutex.Lock()
+	defer app.stopOrdersMutex.Unlock()
+
+	stopOrders = nil
+	tempStopOrders = make(map[string]stopOrder)
+	ocoPairs = nil
+}
+
+func (app *TradeApp) ViewPortfolioBalances(reader *bufio.Reader) error {
 	for {
 		fmt.Println("Enter an asset (e.g., 'eth') or type 'x' to cancel: ")
 		input, err := reader.ReadString('\n')
@@ -332,12 +987,39 @@ func (app *TradeApp) ViewPortfolioBalances() error {
 			fmt.Println("Error fetching balance:", err)
 			continue
 		}
+		if app.jsonOutput() {
+			if err := writeJson(balance); err != nil {
+				return err
+			}
+			continue
+		}
 		fmt.Printf(Blue+"Amount: %s\nHolds: %s\nWithdrawable Amount: %s\nFiat Amount: %s\n"+Reset, balance.Amount, balance.Holds, balance.WithdrawableAmount, balance.FiatAmount)
 	}
 	return nil
 }
 
-func formatToUSD(value string) string {
+func formatFiatBalance(balance Balance) Balance {
+	balance.Amount = formatFiat(balance.Amount)
+	balance.Holds = formatFiat(balance.Holds)
+	balance.WithdrawableAmount = formatFiat(balance.WithdrawableAmount)
+	balance.FiatAmount = formatFiat(balance.FiatAmount)
+	return balance
+}
+
+func (app *TradeApp) isFiat(asset string) bool {
+	fiatAssets := app.FiatAssets
+	if len(fiatAssets) == 0 {
+		fiatAssets = defaultFiatAssets
+	}
+	for _, fiat := range fiatAssets {
+		if strings.EqualFold(fiat, asset) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFiat(value string) string {
 	floatValue, err := strconv.ParseFloat(value, 64)
 	if err != nil {
 		return value
@@ -345,12 +1027,241 @@ func formatToUSD(value string) string {
 	return fmt.Sprintf("%.2f", floatValue)
 }
 
+func (app *TradeApp) GetAllBalances() ([]Balance, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/balances", app.PortfolioId)
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "balance_type=TRADING_BALANCES", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balances: %w", err)
+	}
+
+	var balanceData BalanceResponse
+	if err := json.Unmarshal(body, &balanceData); err != nil {
+		return nil, err
+	}
+	return balanceData.Balances, nil
+}
+
+func (app *TradeApp) ViewAllBalances() error {
+	balances, err := app.GetAllBalances()
+	if err != nil {
+		return err
+	}
+
+	var nonZero []Balance
+	for _, balance := range balances {
+		amount, err := decimal.NewFromString(balance.Amount)
+		if err != nil || amount.IsZero() {
+			continue
+		}
+		nonZero = append(nonZero, balance)
+	}
+	if app.jsonOutput() {
+		if nonZero == nil {
+			nonZero = []Balance{}
+		}
+		return writeJson(nonZero)
+	}
+
+	if len(nonZero) == 0 {
+		fmt.Println("No balances found!")
+		return nil
+	}
+
+	fiatValue := func(balance Balance) decimal.Decimal {
+		value, err := decimal.NewFromString(balance.FiatAmount)
+		if err != nil {
+			return decimal.Zero
+		}
+		return value
+	}
+	sort.SliceStable(nonZero, func(i, j int) bool {
+		return fiatValue(nonZero[i]).GreaterThan(fiatValue(nonZero[j]))
+	})
+
+	total := decimal.Zero
+	fmt.Println(Blue + "Asset  | Amount               | Holds                | Available            | Fiat Value" + Reset)
+	for _, balance := range nonZero {
+		total = total.Add(fiatValue(balance))
+		if app.isFiat(balance.Symbol) {
+			balance = formatFiatBalance(balance)
+		}
+		fmt.Printf(Blue+"%-7s| %-21s| %-21s| %-21s| %s\n"+Reset, strings.ToUpper(balance.Symbol), balance.Amount, balance.Holds, balance.WithdrawableAmount, formatFiat(balance.FiatAmount))
+	}
+	fmt.Printf(Blue+"Total Fiat Value: %s\n"+Reset, total.StringFixed(2))
+	return nil
+}
+
+func (app *TradeApp) CheckRestCredentials() error {
+	path := fmt.Sprintf("/v1/portfolios/%s", app.PortfolioId)
+	if _, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "", nil); err != nil {
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			return fmt.Errorf("REST authentication rejected, check ApiKey, ApiSecret and Passphrase: %w", err)
+		case errors.Is(err, ErrNotFound):
+			return fmt.Errorf("portfolio %s not found, check PortfolioId: %w", app.PortfolioId, err)
+		}
+		return fmt.Errorf("failed to reach Coinbase Prime REST API: %w", err)
+	}
+	return nil
+}
+
+var ErrOrderNotFound = errors.New("order not found")
+
+func (app *TradeApp) GetOrder(orderId string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/orders/%s", app.PortfolioId, url.PathEscape(orderId))
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "", nil)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+		}
+		return nil, err
+	}
+
+	var response struct {
+		Order map[string]interface{} `json:"order"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if response.Order == nil {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+	}
+	return response.Order, nil
+}
+
+func (app *TradeApp) PrintOrder(orderId string) error {
+	order, err := app.GetOrder(orderId)
+	if err != nil {
+		return err
+	}
+
+	orderJson, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(orderJson))
+	return nil
+}
+
+func (app *TradeApp) GetOrderFills(orderId string) ([]Fill, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/orders/%s/fills", app.PortfolioId, url.PathEscape(orderId))
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fills: %w", err)
+	}
+
+	var response FillsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	fills := response.Fills
+	sort.SliceStable(fills, func(i, j int) bool {
+		return parseFillTime(fills[i].Time).Before(parseFillTime(fills[j].Time))
+	})
+	return fills, nil
+}
+
+func parseFillTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+func (app *TradeApp) PrintFills(orderId string) error {
+	fills, err := app.GetOrderFills(orderId)
+	if err != nil {
+		return err
+	}
+	if len(fills) == 0 {
+		fmt.Println("No fills found for this order.")
+		return nil
+	}
+
+	fmt.Println(Blue + "Time                    | Side | Price        | Size         | Fee       | Venue" + Reset)
+	for _, fill := range fills {
+		filledAt := app.formatTimestamp(fill.Time)
+		fmt.Printf(Blue+"%-24s| %-5s| %-13s| %-13s| %-10s| %s\n"+Reset, filledAt, valueOrX(fill.Side), valueOrX(fill.Price), valueOrX(fill.FilledQuantity), valueOrX(fill.Commission), valueOrX(fill.Venue))
+	}
+	return nil
+}
+
+type cachedBalance struct {
+	Balance   Balance
+	FetchedAt time.Time
+}
+
+type balanceStore struct {
+	mutex    sync.RWMutex
+	balances map[string]cachedBalance
+}
+
+func newBalanceStore() *balanceStore {
+	return &balanceStore{balances: make(map[string]cachedBalance)}
+}
+
+func (s *balanceStore) Get(asset string, ttl time.Duration) (Balance, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	cached, ok := s.balances[asset]
+	if !ok || time.Since(cached.FetchedAt) > ttl {
+		return Balance{}, false
+	}
+	return cached.Balance, true
+}
+
+func (s *balanceStore) Set(asset string, balance Balance) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.balances[asset] = cachedBalance{Balance: balance, FetchedAt: time.Now()}
+}
+
+func (s *balanceStore) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.balances = make(map[string]cachedBalance)
+}
+
+func (s *balanceStore) InvalidateProduct(productId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, asset := range strings.Split(strings.ToUpper(productId), "-") {
+		delete(s.balances, asset)
+	}
+}
+
+func (app *TradeApp) balanceCacheTtl() time.Duration {
+	if app.BalanceCacheSeconds <= 0 {
+		return DefaultBalanceCache
+	}
+	return time.Duration(app.BalanceCacheSeconds) * time.Second
+}
+
 func (app *TradeApp) GetAssetBalance(asset string) (Balance, error) {
+	asset, err := normalizeAsset(asset)
+	if err != nil {
+		return Balance{}, err
+	}
+	if balance, ok := app.balances.Get(asset, app.balanceCacheTtl()); ok {
+		return balance, nil
+	}
+
+	balance, err := app.fetchAssetBalance(asset)
+	if err != nil {
+		return Balance{}, err
+	}
+	app.balances.Set(asset, balance)
+	return balance, nil
+}
+
+func (app *TradeApp) fetchAssetBalance(asset string) (Balance, error) {
 	path := fmt.Sprintf("/v1/portfolios/%s/balances", app.PortfolioId)
 	queryParams := fmt.Sprintf("balance_type=TRADING_BALANCES&symbols=%s", asset)
-	body, err := app.makeAuthenticatedRequest("GET", path, queryParams, nil)
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, queryParams, nil)
 	if err != nil {
-		return Balance{}, err
+		return Balance{}, fmt.Errorf("failed to fetch %s balance: %w", asset, err)
 	}
 
 	var balanceData BalanceResponse
@@ -360,11 +1271,8 @@ func (app *TradeApp) GetAssetBalance(asset string) (Balance, error) {
 
 	if len(balanceData.Balances) > 0 {
 		balance := balanceData.Balances[0]
-		if asset == "USD" {
-			balance.Amount = formatToUSD(balance.Amount)
-			balance.Holds = formatToUSD(balance.Holds)
-			balance.WithdrawableAmount = formatToUSD(balance.WithdrawableAmount)
-			balance.FiatAmount = formatToUSD(balance.FiatAmount)
+		if app.isFiat(asset) {
+			balance = formatFiatBalance(balance)
 		}
 		return balance, nil
 	} else {
@@ -372,7 +1280,7 @@ func (app *TradeApp) GetAssetBalance(asset string) (Balance, error) {
 	}
 }
 
-func (app *TradeApp) PreviewOrder(params parsedTradeParams, limitPrice string) error {
+func (app *TradeApp) PreviewOrder(params parsedTradeParams, limitPrice string, reader *bufio.Reader) error {
 	path := fmt.Sprintf("/v1/portfolios/%s/order_preview", app.PortfolioId)
 
 	payload := map[string]string{
@@ -380,7 +1288,12 @@ func (app *TradeApp) PreviewOrder(params parsedTradeParams, limitPrice string) e
 		"client_order_id": uuid.New().String(),
 		"side":            params.Side,
 		"type":            params.OrderType,
-		"base_quantity":   params.BaseQuantity,
+	}
+
+	if params.QuoteQuantity != "" {
+		payload["quote_value"] = params.QuoteQuantity
+	} else {
+		payload["base_quantity"] = params.BaseQuantity
 	}
 
 	if params.OrderType == TradeTypeLimit {
@@ -392,7 +1305,7 @@ func (app *TradeApp) PreviewOrder(params parsedTradeParams, limitPrice string) e
 		return err
 	}
 
-	responseBytes, err := app.makeAuthenticatedRequest("POST", path, "", payloadBytes)
+	responseBytes, err := app.makeAuthenticatedRequest(app.ctx, "POST", path, "", payloadBytes)
 	if err != nil {
 		return err
 	}
@@ -403,7 +1316,7 @@ func (app *TradeApp) PreviewOrder(params parsedTradeParams, limitPrice string) e
 	}
 	printOrderPreview(response)
 
-	app.handlePreviewAction(params, limitPrice)
+	app.handlePreviewAction(params, limitPrice, response, reader)
 
 	return nil
 }
@@ -419,8 +1332,8 @@ func printOrderPreview(response OrderPreviewResponse) {
 	}
 }
 
-func (app *TradeApp) handlePreviewAction(params parsedTradeParams, limitPrice string) {
-	reader := bufio.NewReader(os.Stdin)
+func (app *TradeApp) handlePreviewAction(params parsedTradeParams, limitPrice string, response OrderPreviewResponse, reader *bufio.Reader) {
+	app.printPreviewCosts(response)
 
 	for {
 		fmt.Println("Enter 'g' to submit order or 'x' to create a new order.")
@@ -432,7 +1345,19 @@ func (app *TradeApp) handlePreviewAction(params parsedTradeParams, limitPrice st
 
 		input = strings.TrimSpace(input)
 		if input == "g" {
-			app.ConstructTrade(params, limitPrice, app.SessionId)
+			if !app.validateOrderAgainstFFP(params, limitPrice) {
+				fmt.Println(Red + "Order not submitted, the market has moved outside fat finger limits since the preview." + Reset)
+				break
+			}
+			if !app.confirmBookLiquidity(params, reader) {
+				fmt.Println("Order not submitted.")
+				break
+			}
+			app.printFeeEstimate(params, limitPrice)
+			fmt.Println(Cyan + "Submitting: " + params.summary(limitPrice) + Reset)
+			if _, err := app.ConstructTrade(params, limitPrice, app.SessionId); err != nil {
+				app.logOrderFailure(params, err)
+			}
 			break
 		} else if input == SelectExit {
 			fmt.Println("Returning to order creation...")
@@ -443,6 +1368,11 @@ func (app *TradeApp) handlePreviewAction(params parsedTradeParams, limitPrice st
 	}
 }
 
+// restSignatureMessage builds the request prehash: unix timestamp + HTTP method + path (without query) + body.
+func restSignatureMessage(timestamp, method, path string, body []byte) string {
+	return timestamp + method + path + string(body)
+}
+
 func computeHMAC256(message, secret string) string {
 	key := []byte(secret)
 	h := hmac.New(sha256.New, key)
@@ -450,9 +1380,8 @@ func computeHMAC256(message, secret string) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
-func makeRequest(method, uri string, payload []byte, headers map[string]string) ([]byte, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest(method, uri, bytes.NewBuffer(payload))
+func makeRequest(ctx context.Context, client *http.Client, method, uri string, payload []byte, headers map[string]string, logger Logger) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -461,11 +1390,53 @@ func makeRequest(method, uri string, payload []byte, headers map[string]string)
 		req.Header.Add(key, value)
 	}
 
+	redacted := redactHeaders(headers)
+	logger.Log(LogLevelDebug, "rest_request", fmt.Sprintf("REST S >> %s %s headers=%v body=%s", method, uri, redacted, payload), LogFields{
+		"method":  method,
+		"uri":     uri,
+		"headers": redacted,
+		"body":    string(payload),
+	})
+
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
+		promMetrics.observeRestLatency(method, 0, time.Since(start))
+		logger.Log(LogLevelDebug, "rest_response", fmt.Sprintf("REST R << %s %s error=%v", method, uri, err), LogFields{"method": method, "uri": uri, "error": err})
 		return nil, err
 	}
 	defer resp.Body.Close()
+	promMetrics.observeRestLatency(method, resp.StatusCode, time.Since(start))
 
-	return ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	logger.Log(LogLevelDebug, "rest_response", fmt.Sprintf("REST R << %s %s status=%d body=%s", method, uri, resp.StatusCode, body), LogFields{
+		"method": method,
+		"uri":    uri,
+		"status": resp.StatusCode,
+		"body":   string(body),
+	})
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		statusErr := newHttpStatusError(resp.StatusCode, body)
+		statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, statusErr
+	}
+	return body, nil
+}
+
+// parseRetryAfter accepts either delay-seconds or an HTTP date, returning zero when absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
 }
@@ -0,0 +1,433 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func newTestApp(t *testing.T, restBaseUrl string) *TradeApp {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	app := &TradeApp{
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      NewLogger(false, false),
+		restLimiter: rate.NewLimiter(rate.Inf, 1),
+		httpClient:  &http.Client{},
+		prices:      newPriceStore(),
+		balances:    newBalanceStore(),
+	}
+	app.RestBaseUrl = restBaseUrl
+	app.PortfolioId = "portfolio"
+	app.ApiKey = "key"
+	app.ApiSecret = "secret"
+	app.Passphrase = "passphrase"
+	return app
+}
+
+// captureStdout returns everything fn prints to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-output
+}
+
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string   { return "network error" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+func TestIsRetryableError(t *testing.T) {
+	urlErr := func(err error) error { return &url.Error{Op: "Get", URL: "https://example.com", Err: err} }
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", newHttpStatusError(http.StatusServiceUnavailable, nil), true},
+		{"bad gateway", newHttpStatusError(http.StatusBadGateway, nil), true},
+		{"rate limited", newHttpStatusError(http.StatusTooManyRequests, nil), true},
+		{"bad request", newHttpStatusError(http.StatusBadRequest, nil), false},
+		{"unauthorized", newHttpStatusError(http.StatusUnauthorized, nil), false},
+		{"not found", newHttpStatusError(http.StatusNotFound, nil), false},
+		{"network timeout", urlErr(timeoutError{timeout: true}), true},
+		{"connection reset", urlErr(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"connection refused", urlErr(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"dns failure", urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), false},
+		{"certificate failure", urlErr(x509.UnknownAuthorityError{}), false},
+		{"non-timeout net error", urlErr(timeoutError{timeout: false}), false},
+		{"canceled", urlErr(context.Canceled), false},
+		{"deadline exceeded", fmt.Errorf("GET /v1 timed out: %w", urlErr(context.DeadlineExceeded)), false},
+		{"attempt timed out", fmt.Errorf("%w: GET /v1 after 1s: %v", ErrRequestTimeout, urlErr(context.DeadlineExceeded)), true},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeAuthenticatedRequestRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	body, err := app.makeAuthenticatedRequest(app.ctx, http.MethodGet, "/v1/portfolios/portfolio", "", nil)
+	if err != nil {
+		t.Fatalf("makeAuthenticatedRequest() error = %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %s", body)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestMakeAuthenticatedRequestRetriesAttemptTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(3 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	app.RequestTimeoutSeconds = 1
+	body, err := app.makeAuthenticatedRequest(app.ctx, http.MethodGet, "/v1/portfolios/portfolio", "", nil)
+	if err != nil {
+		t.Fatalf("makeAuthenticatedRequest returned %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %s", body)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestMakeAuthenticatedRequestStopsWhenCallerCancels(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	app.RequestTimeoutSeconds = 5
+	ctx, cancel := context.WithTimeout(app.ctx, 200*time.Millisecond)
+	defer cancel()
+
+	_, err := app.makeAuthenticatedRequest(ctx, http.MethodGet, "/v1/portfolios/portfolio", "", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("error = %v, want the caller's deadline", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestMakeAuthenticatedRequestDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"invalid size"}`))
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	_, err := app.makeAuthenticatedRequest(app.ctx, http.MethodGet, "/v1/portfolios/portfolio", "", nil)
+	if !errors.Is(err, ErrVenueReject) {
+		t.Fatalf("error = %v, want ErrVenueReject", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestMakeAuthenticatedRequestDoesNotRetryPostByDefault(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	_, err := app.makeAuthenticatedRequest(app.ctx, http.MethodPost, "/v1/portfolios/portfolio/order", "", []byte(`{}`))
+	if !errors.Is(err, ErrServerError) {
+		t.Fatalf("error = %v, want ErrServerError", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestPrintOrdersTableHandlesNilFields(t *testing.T) {
+	app := newTestApp(t, "")
+	app.products = map[string]Product{"BTC-USD": {Id: "BTC-USD", BaseIncrement: "0.0001", QuoteIncrement: "0.01"}}
+
+	orders := []interface{}{
+		map[string]interface{}{
+			"id":            "order-1",
+			"product_id":    "BTC-USD",
+			"side":          "BUY",
+			"type":          "MARKET",
+			"limit_price":   nil,
+			"base_quantity": "0.5",
+			"quote_value":   nil,
+		},
+		"not an order",
+	}
+
+	if got := stringField(orders[0].(map[string]interface{}), "limit_price"); got != "" {
+		t.Fatalf("stringField(nil) = %q, want empty", got)
+	}
+
+	var lines int
+	output := captureStdout(t, func() {
+		lines = app.printOrdersTable(orders, true)
+	})
+	if lines != len(orders)+1 {
+		t.Errorf("printOrdersTable returned %d lines, want %d", lines, len(orders)+1)
+	}
+	if !strings.Contains(output, "0.5000") {
+		t.Errorf("expected base quantity in output, got:\n%s", output)
+	}
+
+	captureStdout(t, func() {
+		if err := app.displayAndSelectOrder(orders, true, false, bufio.NewReader(strings.NewReader(SelectExit+"\n"))); err != nil {
+			t.Errorf("displayAndSelectOrder returned %v", err)
+		}
+	})
+}
+
+func TestRestSignature(t *testing.T) {
+	body := []byte(`{"side":"BUY"}`)
+	message := restSignatureMessage("1700000000", "POST", "/v1/portfolios/p1/order", body)
+	if want := `1700000000POST/v1/portfolios/p1/order{"side":"BUY"}`; message != want {
+		t.Fatalf("restSignatureMessage() = %q, want %q", message, want)
+	}
+
+	if got, want := computeHMAC256(message, "test-secret"), "PTNdstyD6t4TqklsU48PF9LD8Wy1AmEEaHTKQg73y7Q="; got != want {
+		t.Errorf("computeHMAC256() = %q, want %q", got, want)
+	}
+}
+
+func TestCheckCancelResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "acknowledged", body: `{"id":"order-1"}`},
+		{name: "different order", body: `{"id":"order-2"}`, wantErr: true},
+		{name: "missing id", body: `{}`, wantErr: true},
+		{name: "unreadable", body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCancelResponse("order-1", []byte(tt.body))
+			if tt.wantErr && !errors.Is(err, ErrCancelRejected) {
+				t.Errorf("checkCancelResponse(%s) = %v, want ErrCancelRejected", tt.body, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkCancelResponse(%s) = %v, want nil", tt.body, err)
+			}
+		})
+	}
+}
+
+func TestCancelOrderRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{name: "order already filled", status: OrderStatusFilled},
+		{name: "order still open", status: "OPEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cancels int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/cancel"):
+					atomic.AddInt32(&cancels, 1)
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"message":"order cannot be cancelled"}`))
+				case r.Method == http.MethodGet:
+					fmt.Fprintf(w, `{"order":{"id":"order-1","status":%q}}`, tt.status)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			app := newTestApp(t, server.URL)
+			err := app.CancelOrder("order-1", "")
+			if !errors.Is(err, ErrCancelRejected) {
+				t.Fatalf("CancelOrder() = %v, want ErrCancelRejected", err)
+			}
+			if cancels != 1 {
+				t.Errorf("cancel requests = %d, want 1", cancels)
+			}
+		})
+	}
+}
+
+func TestCancelOrderRetriesOnce(t *testing.T) {
+	var cancels int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"order":{"id":"order-1","client_order_id":"cl-1","status":"OPEN"}}`))
+			return
+		}
+		atomic.AddInt32(&cancels, 1)
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"client_order_id":"cl-1"`) {
+			t.Errorf("cancel payload %s is missing the client order id", body)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, server.URL)
+	app.MaxRetries = 1
+	app.RetryPostRequests = true
+
+	if err := app.CancelOrder("order-1", "cl-1"); !errors.Is(err, ErrServerError) {
+		t.Fatalf("CancelOrder() = %v, want ErrServerError", err)
+	}
+	if cancels != 2 {
+		t.Errorf("cancel requests = %d, want 2", cancels)
+	}
+}
+
+func TestIsFiat(t *testing.T) {
+	tests := []struct {
+		asset      string
+		fiatAssets []string
+		want       bool
+	}{
+		{asset: "usd", want: true},
+		{asset: "USD", want: true},
+		{asset: "eur", want: true},
+		{asset: "ETH", want: false},
+		{asset: "eur", fiatAssets: []string{"USD"}, want: false},
+		{asset: "chf", fiatAssets: []string{"usd", "CHF"}, want: true},
+	}
+
+	for _, tt := range tests {
+		app := newTestApp(t, "")
+		app.FiatAssets = tt.fiatAssets
+		if got := app.isFiat(tt.asset); got != tt.want {
+			t.Errorf("isFiat(%q) with FiatAssets %v = %v, want %v", tt.asset, tt.fiatAssets, got, tt.want)
+		}
+	}
+}
+
+func TestGetAssetBalanceFormatting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.URL.Query().Get("symbols")
+		fmt.Fprintf(w, `{"balances":[{"symbol":%q,"amount":"1234.56789","holds":"0.1","withdrawable_amount":"1234.46789","fiat_amount":"1234.56789"}]}`, symbol)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		asset        string
+		wantAmount   string
+		wantHolds    string
+		wantSymbol   string
+		wantWithdraw string
+	}{
+		{asset: "usd", wantSymbol: "USD", wantAmount: "1234.57", wantHolds: "0.10", wantWithdraw: "1234.47"},
+		{asset: "USD", wantSymbol: "USD", wantAmount: "1234.57", wantHolds: "0.10", wantWithdraw: "1234.47"},
+		{asset: "eur", wantSymbol: "EUR", wantAmount: "1234.57", wantHolds: "0.10", wantWithdraw: "1234.47"},
+		{asset: "eth", wantSymbol: "ETH", wantAmount: "1234.56789", wantHolds: "0.1", wantWithdraw: "1234.46789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			app := newTestApp(t, server.URL)
+			balance, err := app.GetAssetBalance(tt.asset)
+			if err != nil {
+				t.Fatalf("GetAssetBalance(%q) returned %v", tt.asset, err)
+			}
+			if balance.Symbol != tt.wantSymbol || balance.Amount != tt.wantAmount || balance.Holds != tt.wantHolds || balance.WithdrawableAmount != tt.wantWithdraw {
+				t.Errorf("GetAssetBalance(%q) = %+v, want symbol %s amount %s holds %s withdrawable %s", tt.asset, balance, tt.wantSymbol, tt.wantAmount, tt.wantHolds, tt.wantWithdraw)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultMaxSlippagePct   = "0.5"
+	DefaultMaxDepthFraction = "0.5"
+)
+
+type dailyNotionalTracker struct {
+	mutex sync.Mutex
+	day   string
+	used  decimal.Decimal
+}
+
+func (t *dailyNotionalTracker) rollover(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if t.day != day {
+		t.day = day
+		t.used = decimal.Zero
+	}
+}
+
+func (t *dailyNotionalTracker) Reserve(amount, limit decimal.Decimal, now time.Time) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.rollover(now)
+	if limit.IsPositive() && t.used.Add(amount).GreaterThan(limit) {
+		return fmt.Errorf("order notional %s would exceed the daily limit of %s (used %s today)", amount.StringFixed(2), limit.String(), t.used.StringFixed(2))
+	}
+	t.used = t.used.Add(amount)
+	return nil
+}
+
+func (t *dailyNotionalTracker) Release(amount decimal.Decimal) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.used = decimal.Max(t.used.Sub(amount), decimal.Zero)
+}
+
+func (t *dailyNotionalTracker) Usage(now time.Time) decimal.Decimal {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.rollover(now)
+	return t.used
+}
+
+func (app *TradeApp) estimateNotional(params parsedTradeParams, limitPrice string) (decimal.Decimal, bool) {
+	if params.QuoteQuantity != "" {
+		quoteQuantity, err := decimal.NewFromString(params.QuoteQuantity)
+		return quoteQuantity, err == nil
+	}
+
+	quantity, err := decimal.NewFromString(params.BaseQuantity)
+	if err != nil {
+		return decimal.Zero, false
+	}
+
+	if params.OrderType == TradeTypeLimit {
+		price, err := decimal.NewFromString(limitPrice)
+		if err != nil {
+			return decimal.Zero, false
+		}
+		return quantity.Mul(price), true
+	}
+
+	priceData, exists := app.prices.Get(params.Product)
+	if !exists {
+		return decimal.Zero, false
+	}
+	price, err := decimal.NewFromString(priceData.Price)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return quantity.Mul(price), true
+}
+
+func (app *TradeApp) validateMinimumSize(params parsedTradeParams, limitPrice string) error {
+	minimum := app.orderMinimum(params.Product)
+
+	if params.QuoteQuantity == "" && minimum.BaseQuantity.IsPositive() {
+		quantity, err := decimal.NewFromString(params.BaseQuantity)
+		if err == nil && quantity.LessThan(minimum.BaseQuantity) {
+			return fmt.Errorf("order size %s is below the minimum of %s for %s", params.BaseQuantity, minimum.BaseQuantity.String(), params.Product)
+		}
+	}
+
+	if minimum.Notional.IsPositive() {
+		if notional, ok := app.estimateNotional(params, limitPrice); ok && notional.LessThan(minimum.Notional) {
+			return fmt.Errorf("order notional %s is below the minimum of %s for %s", notional.String(), minimum.Notional.String(), params.Product)
+		}
+	}
+	return nil
+}
+
+func (app *TradeApp) feeRateBps() decimal.Decimal {
+	if app.FeeRateBps == "" {
+		return decimal.Zero
+	}
+	rate, err := decimal.NewFromString(app.FeeRateBps)
+	if err != nil {
+		return decimal.Zero
+	}
+	return rate
+}
+
+func (app *TradeApp) estimateFee(params parsedTradeParams, limitPrice string) (decimal.Decimal, decimal.Decimal, bool) {
+	rate := app.feeRateBps()
+	if !rate.IsPositive() {
+		return decimal.Zero, decimal.Zero, false
+	}
+
+	notional, ok := app.estimateNotional(params, limitPrice)
+	if !ok {
+		return decimal.Zero, decimal.Zero, false
+	}
+
+	fee := notional.Mul(rate).Div(decimal.NewFromInt(10000))
+	if params.Side == TradeSideSell {
+		return fee, notional.Sub(fee), true
+	}
+	return fee, notional.Add(fee), true
+}
+
+func (app *TradeApp) printFeeEstimate(params parsedTradeParams, limitPrice string) {
+	fee, net, ok := app.estimateFee(params, limitPrice)
+	if !ok {
+		return
+	}
+	fmt.Printf(Blue+"Est. fee: %s (%s bps) | Net notional: %s\n"+Reset, fee.StringFixed(2), app.feeRateBps().String(), net.StringFixed(2))
+}
+
+func (app *TradeApp) maxSlippagePct() decimal.Decimal {
+	pct, err := decimal.NewFromString(app.MaxSlippagePct)
+	if err != nil {
+		return decimal.RequireFromString(DefaultMaxSlippagePct)
+	}
+	return pct
+}
+
+func parsePreviewDecimal(value string) decimal.Decimal {
+	if value == "" {
+		return decimal.Zero
+	}
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero
+	}
+	return parsed
+}
+
+// printPreviewCosts reports the all-in cost of a previewed order and warns when the
+// preview's slippage, a fraction of the order price, exceeds MaxSlippagePct.
+func (app *TradeApp) printPreviewCosts(response OrderPreviewResponse) {
+	commission := parsePreviewDecimal(response.Commission)
+	orderTotal := parsePreviewDecimal(response.OrderTotal)
+	fmt.Printf(Blue+"All-in cost: %s (order total %s + commission %s)\n"+Reset, orderTotal.Add(commission).StringFixed(2), orderTotal.StringFixed(2), commission.StringFixed(2))
+
+	slippagePct := parsePreviewDecimal(response.Slippage).Abs().Mul(decimal.NewFromInt(100))
+	if maxPct := app.maxSlippagePct(); slippagePct.GreaterThan(maxPct) {
+		fmt.Printf(Red+"Warning: Estimated slippage of %s%% exceeds %s%%, the book may be too thin for this order.\n"+Reset, slippagePct.StringFixed(2), maxPct.String())
+	}
+}
+
+func (app *TradeApp) maxDepthFraction() decimal.Decimal {
+	fraction, err := decimal.NewFromString(app.MaxDepthFraction)
+	if err != nil {
+		return decimal.RequireFromString(DefaultMaxDepthFraction)
+	}
+	return fraction
+}
+
+// liveOrderBook returns the product's order book if it has been updated within the price staleness window.
+func (app *TradeApp) liveOrderBook(product string) (*OrderBookProcessor, bool) {
+	book, ok := app.OrderBooks[product]
+	if !ok || book == nil || time.Since(book.UpdatedAt) > app.maxPriceAge() {
+		return nil, false
+	}
+	return book, true
+}
+
+func (app *TradeApp) confirmBookLiquidity(params parsedTradeParams, reader *bufio.Reader) bool {
+	if params.OrderType != TradeTypeMarket {
+		return true
+	}
+	book, ok := app.liveOrderBook(params.Product)
+	if !ok {
+		return true
+	}
+
+	bids, offers := book.CumulativeDepth()
+	size, unit := params.BaseQuantity, "base"
+	if params.QuoteQuantity != "" {
+		bids, offers = book.CumulativeNotional()
+		size, unit = params.QuoteQuantity, "quote"
+	}
+	depth := offers
+	if params.Side == TradeSideSell {
+		depth = bids
+	}
+
+	orderSize, err := decimal.NewFromString(size)
+	if err != nil {
+		return true
+	}
+	visible := decimal.Zero
+	if len(depth) > 0 {
+		visible = decimal.NewFromFloat(depth[len(depth)-1])
+	}
+
+	limit := visible.Mul(app.maxDepthFraction())
+	if orderSize.LessThanOrEqual(limit) {
+		return true
+	}
+
+	fmt.Printf(Red+"Warning: Order size %s exceeds %s%% of the visible %s depth for %s (%s %s).\n"+Reset,
+		orderSize.String(), app.maxDepthFraction().Mul(decimal.NewFromInt(100)).String(), params.Side, params.Product, visible.String(), unit)
+	return promptYesNo("Submit anyway? (y/n)", reader)
+}
+
+func (app *TradeApp) dailyNotionalLimit() decimal.Decimal {
+	if app.DailyNotionalLimit == "" {
+		return decimal.Zero
+	}
+	limit, err := decimal.NewFromString(app.DailyNotionalLimit)
+	if err != nil {
+		return decimal.Zero
+	}
+	return limit
+}
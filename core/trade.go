@@ -17,11 +17,12 @@ limitations under the License.
 package core
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/shopspring/decimal"
-	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/quickfixgo/quickfix"
 )
@@ -31,6 +32,27 @@ type parsedTradeParams struct {
 	OrderType    string
 	Side         string
 	BaseQuantity string
+	// QuoteQuantity sizes a market order by quote amount, e.g. $500 of ETH, instead of BaseQuantity.
+	QuoteQuantity string
+	TimeInForce   string
+	ExpireTime    time.Time
+	// StopPrice turns a limit order into a venue-side stop-limit that rests until the market trades through it.
+	StopPrice string
+}
+
+type lastOrder struct {
+	Params     parsedTradeParams
+	LimitPrice string
+}
+
+type replaceOrderParams struct {
+	OrderId      string
+	OrigClOrdId  string
+	Product      string
+	Side         string
+	OrderType    string
+	BaseQuantity string
+	LimitPrice   string
 }
 
 type stopOrder struct {
@@ -38,15 +60,33 @@ type stopOrder struct {
 	Side          string
 	Amount        float64
 	StopPrice     decimal.Decimal
+	LimitPrice    decimal.Decimal
 	PlacedOrderId string
-	BaseQuantity  string
+	// ClOrdId is the client order id of the linked order, sent as the idempotency key when it is cancelled.
+	ClOrdId      string
+	BaseQuantity string
+	Triggered    bool
+	TrailOffset  decimal.Decimal
+	TrailPercent bool
+	// HighWaterMark is the best price seen since placement: the high for sells, the low for buys.
+	HighWaterMark decimal.Decimal
 }
 
 var tempStopOrders = make(map[string]stopOrder)
 
-func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
+func (app *TradeApp) ProcessSimpleTradeInput(args []string, reader *bufio.Reader) {
 	isPreview := false
 	isOco := false
+	isLinkedOco := false
+	isStop := false
+	isTrail := false
+	isTwap := false
+	isIceberg := false
+	var expireTime time.Time
+	var displayQty decimal.Decimal
+	var trailArg string
+	var twapSlices int
+	var twapInterval time.Duration
 	var ocoPrice decimal.Decimal
 	var err error
 	var clOrdId string
@@ -59,7 +99,7 @@ func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
 			isPreview = true
 			args = append(args[:i], args[i+1:]...)
 			i--
-		case "-oco":
+		case ArgOco:
 			isOco = true
 			if i+1 < len(args) {
 				ocoPrice, err = decimal.NewFromString(args[i+1])
@@ -73,6 +113,90 @@ func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
 				fmt.Println("Error: -oco flag should be followed by a valid price.")
 				return
 			}
+		case ArgStop:
+			isStop = true
+			if i+1 < len(args) {
+				ocoPrice, err = decimal.NewFromString(args[i+1])
+				if err != nil {
+					fmt.Println("Error: Invalid stop price.")
+					return
+				}
+				args = append(args[:i], args[i+2:]...)
+				i -= 2
+			} else {
+				fmt.Println("Error: -stop flag should be followed by a valid stop price.")
+				return
+			}
+		case ArgTwap:
+			isTwap = true
+			if i+2 < len(args) {
+				twapSlices, err = strconv.Atoi(args[i+1])
+				if err != nil || twapSlices < 1 {
+					fmt.Println("Error: Invalid TWAP slice count.")
+					return
+				}
+				twapInterval, err = time.ParseDuration(args[i+2])
+				if err != nil || twapInterval <= 0 {
+					fmt.Println("Error: Invalid TWAP interval, e.g. 30s or 5m.")
+					return
+				}
+				args = append(args[:i], args[i+3:]...)
+				i -= 3
+			} else {
+				fmt.Println("Error: -twap flag should be followed by a slice count and interval, e.g. -twap 5 1m.")
+				return
+			}
+		case ArgIceberg:
+			isIceberg = true
+			if i+1 < len(args) {
+				displayQty, err = decimal.NewFromString(args[i+1])
+				if err != nil {
+					fmt.Println("Error: Invalid iceberg display size.")
+					return
+				}
+				args = append(args[:i], args[i+2:]...)
+				i -= 2
+			} else {
+				fmt.Println("Error: -ice flag should be followed by a display size.")
+				return
+			}
+		case ArgGtt:
+			if i+1 < len(args) {
+				expireTime, err = time.Parse(time.RFC3339, strings.ToUpper(args[i+1]))
+				if err != nil {
+					fmt.Println("Error: Invalid -gtt expiry, expected RFC 3339 e.g. 2024-01-01T15:00:00Z.")
+					return
+				}
+				args = append(args[:i], args[i+2:]...)
+				i -= 2
+			} else {
+				fmt.Println("Error: -gtt flag should be followed by an expiry time.")
+				return
+			}
+		case ArgTrail:
+			isTrail = true
+			if i+1 < len(args) {
+				trailArg = args[i+1]
+				args = append(args[:i], args[i+2:]...)
+				i -= 2
+			} else {
+				fmt.Println("Error: -trail flag should be followed by an offset, e.g. 50 or 2%.")
+				return
+			}
+		case ArgLinkedOco:
+			isLinkedOco = true
+			if i+1 < len(args) {
+				ocoPrice, err = decimal.NewFromString(args[i+1])
+				if err != nil {
+					fmt.Println("Error: Invalid linked OCO stop price.")
+					return
+				}
+				args = append(args[:i], args[i+2:]...)
+				i -= 2
+			} else {
+				fmt.Println("Error: -link flag should be followed by a valid stop price.")
+				return
+			}
 		case "h":
 			printHelp()
 			return
@@ -80,8 +204,19 @@ func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
 		i++
 	}
 
-	if isPreview && isOco {
-		fmt.Println("Error: -p and -oco flags cannot be used together.")
+	if isPreview && (isOco || isLinkedOco || isStop || isTrail || isTwap || isIceberg) {
+		fmt.Println("Error: -p cannot be used with -oco, -link, -stop, -trail, -twap or -ice.")
+		return
+	}
+
+	flagCount := 0
+	for _, set := range []bool{isOco, isLinkedOco, isStop, isTrail, isTwap, isIceberg} {
+		if set {
+			flagCount++
+		}
+	}
+	if flagCount > 1 {
+		fmt.Println("Error: -oco, -link, -stop, -trail, -twap and -ice flags cannot be used together.")
 		return
 	}
 
@@ -96,8 +231,40 @@ func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
 		return
 	}
 
-	if isOco && params.OrderType != TradeTypeLimit {
-		fmt.Println("Error: -oco can only be used with limit (lim) orders.")
+	if params.TimeInForce == TimeInForceIOC || params.TimeInForce == TimeInForceFOK {
+		if isOco || isLinkedOco || isStop || isTrail || isIceberg || !expireTime.IsZero() {
+			fmt.Println("Error: ioc and fok cannot be used with -oco, -link, -stop, -trail, -ice or -gtt.")
+			return
+		}
+	}
+
+	if !expireTime.IsZero() {
+		if params.TimeInForce == TimeInForceGTC {
+			fmt.Println("Error: gtc cannot be used with -gtt.")
+			return
+		}
+		if params.OrderType != TradeTypeLimit {
+			fmt.Println("Error: -gtt can only be used with limit (lim) orders.")
+			return
+		}
+		if isPreview || isStop || isTrail || isTwap {
+			fmt.Println("Error: -gtt cannot be used with -p, -stop, -trail or -twap.")
+			return
+		}
+		if !expireTime.After(time.Now()) {
+			fmt.Println("Error: -gtt expiry must be in the future.")
+			return
+		}
+		params.ExpireTime = expireTime
+	}
+
+	if params.QuoteQuantity != "" && (isTrail || isTwap) {
+		fmt.Println("Error: quote quantity orders cannot be used with -trail or -twap.")
+		return
+	}
+
+	if (isOco || isLinkedOco || isStop) && params.OrderType != TradeTypeLimit {
+		fmt.Println("Error: -oco, -link and -stop can only be used with limit (lim) orders.")
 		return
 	}
 
@@ -111,119 +278,498 @@ func (app *TradeApp) ProcessSimpleTradeInput(args []string) {
 		limitPriceStr = ""
 	}
 
-	amount, err := strconv.ParseFloat(params.BaseQuantity, 64)
-	if err != nil {
-		fmt.Println("Error: Invalid order size.")
-		return
+	var amount float64
+	if params.QuoteQuantity == "" {
+		amount, err = strconv.ParseFloat(params.BaseQuantity, 64)
+		if err != nil {
+			fmt.Println("Error: Invalid order size.")
+			return
+		}
 	}
 
-	if isOco && (params.Side == ArgBuy && ocoPrice.LessThanOrEqual(limitPrice) || params.Side == ArgSell && ocoPrice.GreaterThanOrEqual(limitPrice)) {
+	if isOco && (params.Side == TradeSideBuy && ocoPrice.LessThanOrEqual(limitPrice) || params.Side == TradeSideSell && ocoPrice.GreaterThanOrEqual(limitPrice)) {
 		fmt.Println("Error: Invalid relationship between order price and OCO price.")
 		return
 	}
 
-	if !app.validateOrderAgainstFFP(params.Product, params.Side, params.OrderType, limitPriceStr, amount) {
+	minimumParams := params
+	if isTwap {
+		totalQty, _ := decimal.NewFromString(params.BaseQuantity)
+		minimumParams.BaseQuantity = totalQty.Div(decimal.NewFromInt(int64(twapSlices))).String()
+	} else if isIceberg {
+		minimumParams.BaseQuantity = displayQty.String()
+	}
+	if err := app.validateMinimumSize(minimumParams, limitPriceStr); err != nil {
+		fmt.Printf(Red+"Error: %v\n"+Reset, err)
+		return
+	}
+
+	if isTwap {
+		totalQty, _ := decimal.NewFromString(params.BaseQuantity)
+		if !app.confirmLargeOrder(params, limitPriceStr, reader) {
+			fmt.Println("Order not submitted.")
+			return
+		}
+		id, err := app.SubmitTwap(params, totalQty, twapSlices, twapInterval)
+		if err != nil {
+			fmt.Printf(Red+"Error: %v\n"+Reset, err)
+			return
+		}
+		fmt.Printf(Blue+"TWAP #%d started: %s %s %s in %d slices every %s. Type '%s' to stop.\n"+Reset, id, params.Side, params.BaseQuantity, params.Product, twapSlices, twapInterval, ArgCancelTwap)
+		return
+	}
+
+	if !app.validateOrderAgainstFFP(params, limitPriceStr) {
 		return
 	}
 
 	if isPreview {
-		if err := app.PreviewOrder(params, limitPriceStr); err != nil {
-			log.Printf("Failed to preview order: %v", err)
+		if err := app.PreviewOrder(params, limitPriceStr, reader); err != nil {
+			fmt.Printf(Red+"Failed to preview order: %v\n"+Reset, err)
+		}
+		return
+	}
+
+	if !app.confirmBookLiquidity(params, reader) {
+		fmt.Println("Order not submitted.")
+		return
+	}
+
+	app.printFeeEstimate(params, limitPriceStr)
+
+	if !app.confirmLargeOrder(params, limitPriceStr, reader) {
+		fmt.Println("Order not submitted.")
+		return
+	}
+
+	if isTrail {
+		order, err := app.newTrailingStop(params, amount, limitPrice, trailArg)
+		if err != nil {
+			fmt.Printf(Red+"Error: %v\n"+Reset, err)
+			return
 		}
+		app.stopOrdersMutex.Lock()
+		stopOrders = append(stopOrders, order)
+		app.stopOrdersMutex.Unlock()
+		fmt.Printf(Blue+"Trailing stop added: %s %s %s, trail %s, initial stop %s\n"+Reset, params.Side, params.BaseQuantity, params.Product, trailArg, formatIncrement(order.StopPrice.String(), app.productIncrements(params.Product).Quote))
 		return
 	}
 
-	clOrdId = app.ConstructTrade(params, limitPriceStr, app.SessionId)
+	if isIceberg {
+		id, err := app.SubmitIceberg(params, limitPriceStr, displayQty)
+		if err != nil {
+			app.logOrderFailure(params, err)
+			return
+		}
+		fmt.Printf(Blue+"Iceberg #%d started: %s %s %s @ %s showing %s at a time\n"+Reset, id, params.Side, params.BaseQuantity, params.Product, limitPriceStr, displayQty.String())
+		return
+	}
 
-	if isOco {
-		newOrder = stopOrder{
+	if isStop {
+		app.stopOrdersMutex.Lock()
+		stopOrders = append(stopOrders, stopOrder{
 			Product:      params.Product,
 			Side:         params.Side,
 			BaseQuantity: params.BaseQuantity,
 			Amount:       amount,
 			StopPrice:    ocoPrice,
+			LimitPrice:   limitPrice,
+		})
+		app.stopOrdersMutex.Unlock()
+		fmt.Printf(Blue+"Stop order added: %s %s %s @ %s when price crosses %s\n"+Reset, params.Side, params.BaseQuantity, params.Product, limitPrice.String(), ocoPrice.String())
+		return
+	}
+
+	if isLinkedOco {
+		if params.Side == TradeSideBuy && ocoPrice.LessThanOrEqual(limitPrice) || params.Side == TradeSideSell && ocoPrice.GreaterThanOrEqual(limitPrice) {
+			fmt.Println("Error: Invalid relationship between take profit price and stop price.")
+			return
+		}
+		if err := app.SubmitOco(params, limitPriceStr, ocoPrice.String()); err != nil {
+			app.logOrderFailure(params, err)
 		}
-		tempStopOrders[clOrdId] = newOrder
+		return
 	}
+
+	if !isOco {
+		clOrdId, err = app.ConstructTrade(params, limitPriceStr, app.SessionId)
+		if err != nil {
+			app.logOrderFailure(params, err)
+			return
+		}
+		app.lastOrder = &lastOrder{Params: params, LimitPrice: limitPriceStr}
+		return
+	}
+
+	newOrder = stopOrder{
+		Product:      params.Product,
+		Side:         params.Side,
+		BaseQuantity: params.BaseQuantity,
+		Amount:       amount,
+		StopPrice:    ocoPrice,
+	}
+	if params.Side == TradeSideSell {
+		newOrder.LimitPrice = ocoPrice
+	}
+
+	// Hold the lock across the send so the order's first exec report waits in getExecType until
+	// its pending stop is registered.
+	app.stopOrdersMutex.Lock()
+	defer app.stopOrdersMutex.Unlock()
+	clOrdId, err = app.ConstructTrade(params, limitPriceStr, app.SessionId)
+	if err != nil {
+		app.logOrderFailure(params, err)
+		return
+	}
+	tempStopOrders[clOrdId] = newOrder
+}
+
+func (app *TradeApp) ReplayLastOrder(reader *bufio.Reader) {
+	if app.lastOrder == nil {
+		fmt.Println("Error: No order to replay this session.")
+		return
+	}
+
+	params, limitPrice := app.lastOrder.Params, app.lastOrder.LimitPrice
+	if !params.ExpireTime.IsZero() && !params.ExpireTime.After(time.Now()) {
+		fmt.Println("Error: The last order's -gtt expiry has passed, enter it again with a new expiry.")
+		return
+	}
+	if limitPrice == "" {
+		fmt.Printf(Blue+"Replaying: %s %s %s %s\n"+Reset, params.Product, params.OrderType, params.Side, params.quantityString())
+	} else {
+		fmt.Printf(Blue+"Replaying: %s %s %s %s @ %s\n"+Reset, params.Product, params.OrderType, params.Side, params.quantityString(), limitPrice)
+	}
+
+	if !app.validateOrderAgainstFFP(params, limitPrice) {
+		return
+	}
+
+	if !app.confirmBookLiquidity(params, reader) {
+		fmt.Println("Order not submitted.")
+		return
+	}
+
+	app.printFeeEstimate(params, limitPrice)
+
+	if !app.confirmLargeOrder(params, limitPrice, reader) {
+		fmt.Println("Order not submitted.")
+		return
+	}
+
+	if _, err := app.ConstructTrade(params, limitPrice, app.SessionId); err != nil {
+		app.logOrderFailure(params, err)
+	}
+}
+
+func (app *TradeApp) confirmLargeOrder(params parsedTradeParams, limitPrice string, reader *bufio.Reader) bool {
+	threshold := app.confirmThreshold()
+	if !threshold.IsPositive() {
+		return true
+	}
+
+	notional, ok := app.estimateNotional(params, limitPrice)
+	if !ok || notional.LessThanOrEqual(threshold) {
+		return true
+	}
+
+	return promptYesNo(fmt.Sprintf("Confirm %s %s %s %s (est. notional %s)? (y/n)", params.Side, params.quantityString(), params.Product, params.OrderType, notional.StringFixed(2)), reader)
+}
+
+func promptYesNo(prompt string, reader *bufio.Reader) bool {
+	for {
+		fmt.Println(Yellow + prompt + Reset)
+		input, err := GetUserInput(reader)
+		if err != nil {
+			return false
+		}
+
+		switch strings.ToLower(input) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		default:
+			fmt.Println("Invalid input. Please enter 'y' or 'n'.")
+		}
+	}
+}
+
+func (app *TradeApp) confirmThreshold() decimal.Decimal {
+	if app.ConfirmThreshold == "" {
+		return decimal.Zero
+	}
+	threshold, err := decimal.NewFromString(app.ConfirmThreshold)
+	if err != nil {
+		return decimal.Zero
+	}
+	return threshold
 }
 
 func printHelp() {
 	fmt.Println(Purple + "Accepts market (mkt) and limit (lim) base quantity orders.")
+	fmt.Println("Prefix a market order quantity with '$' to size it in the quote currency instead.")
 	fmt.Println("Append '-p' to submit an order preview over REST.")
 	fmt.Println("Append '-oco' to submit an OCO order. Manage OCOs from main menu.")
-	fmt.Println("Format: product mkt/lim b/s lim_price base_quantity")
+	fmt.Println("Append '-stop' to hold a limit order client-side until the stop price is crossed.")
+	fmt.Println("Append '-link' to submit two linked limit orders where a fill on one cancels the other.")
+	fmt.Println("Append '-trail' with an absolute or percent offset to hold a stop that follows the price.")
+	fmt.Println("Append '-twap slices interval' to split a market order into equal child orders over time.")
+	fmt.Println("Append '-ice size' to work a limit order in slices of the given display size.")
+	fmt.Println("Append '-gtt time' to a limit order to cancel it automatically at an RFC 3339 time.")
+	fmt.Println("Format: product mkt/lim b/s lim_price base_quantity [gtc/ioc/fok]")
+	fmt.Println("Limit orders default to gtc. Add ioc or fok to fill immediately without resting; market orders are always ioc.")
 	fmt.Println("Ex: eth-usd mkt s 0.001")
+	fmt.Println("Ex: eth-usd mkt b $500")
 	fmt.Println("Ex: eth-usd lim b 1400 0.001")
+	fmt.Println("Ex: eth-usd lim b 1400 0.001 fok")
 	fmt.Println("Ex: ltc-usd lim s 100 15 -p")
-	fmt.Println("Ex: eth-usd lim b 1500 0.001 -oco 2000\n" + Reset)
+	fmt.Println("Ex: eth-usd lim b 1500 0.001 -oco 2000")
+	fmt.Println("Ex: eth-usd lim s 1450 0.001 -stop 1500")
+	fmt.Println("Ex: eth-usd lim s 2000 0.001 -link 1500")
+	fmt.Println("Ex: eth-usd mkt s 0.001 -trail 2%")
+	fmt.Println("Ex: eth-usd mkt b 1 -twap 5 1m")
+	fmt.Println("Ex: eth-usd lim b 1500 10 -ice 1")
+	fmt.Println("Ex: eth-usd lim b 1400 0.001 -gtt 2024-01-01T15:00:00Z")
+	fmt.Println("Type '.' or 'again' to resubmit the last order sent this session.")
+	fmt.Println("Type 'cancel-twap' to stop all running TWAP schedules.")
+	fmt.Println("Type 'refresh' to fetch balances and prices again instead of using cached values.")
+	fmt.Println("Type 'panic' to cancel all open orders and clear pending stops.\n" + Reset)
 }
 
 func parseArgs(args []string) (parsedTradeParams, string, error) {
-	product := strings.ToUpper(args[0])
-	orderType := getTradeType(args[1])
-	side := getTradeSide(args[2])
-	baseQuantity := args[3]
+	if len(args) < MinRequiredArgs {
+		return parsedTradeParams{}, "", fmt.Errorf("insufficient parameters: expected product mkt/lim b/s [lim_price] base_quantity")
+	}
+
+	orderType, err := getTradeType(args[1])
+	if err != nil {
+		return parsedTradeParams{}, "", err
+	}
+
+	side, err := getTradeSide(args[2])
+	if err != nil {
+		return parsedTradeParams{}, "", err
+	}
+
+	product, err := normalizeProduct(args[0])
+	if err != nil {
+		return parsedTradeParams{}, "", err
+	}
 
 	params := parsedTradeParams{
-		Product:      product,
-		OrderType:    orderType,
-		Side:         side,
-		BaseQuantity: baseQuantity,
+		Product:   product,
+		OrderType: orderType,
+		Side:      side,
 	}
 
-	if params.OrderType == TradeTypeLimit {
-		if len(args) <= 4 {
-			return params, "", fmt.Errorf("limit price required for limit order")
+	switch orderType {
+	case TradeTypeMarket:
+		if len(args) != MarketOrderArgs {
+			return parsedTradeParams{}, "", fmt.Errorf("market order expects %d parameters (product mkt b/s base_quantity), got %d", MarketOrderArgs, len(args))
+		}
+		if strings.HasPrefix(args[3], ArgQuotePrefix) {
+			params.QuoteQuantity = strings.TrimPrefix(args[3], ArgQuotePrefix)
+		} else {
+			params.BaseQuantity = args[3]
+		}
+		return params, "", nil
+	default:
+		if len(args) != LimitOrderArgs && len(args) != LimitOrderArgs+1 {
+			return parsedTradeParams{}, "", fmt.Errorf("limit order expects %d parameters (product lim b/s lim_price base_quantity [gtc/ioc/fok]), got %d", LimitOrderArgs, len(args))
+		}
+		if strings.HasPrefix(args[4], ArgQuotePrefix) {
+			return parsedTradeParams{}, "", fmt.Errorf("quote quantity ($) is only supported for market orders")
 		}
-		limitPrice := args[3]
 		params.BaseQuantity = args[4]
-		return params, limitPrice, nil
+		if len(args) == LimitOrderArgs+1 {
+			params.TimeInForce, err = getTimeInForce(args[5])
+			if err != nil {
+				return parsedTradeParams{}, "", err
+			}
+		}
+		return params, args[3], nil
 	}
+}
+
+func (params parsedTradeParams) quantityString() string {
+	if params.QuoteQuantity != "" {
+		return ArgQuotePrefix + params.QuoteQuantity
+	}
+	return params.BaseQuantity
+}
 
-	return params, "", nil
+func (params parsedTradeParams) summary(limitPrice string) string {
+	summary := fmt.Sprintf("%s %s %s %s", params.Side, params.quantityString(), params.Product, params.OrderType)
+	if limitPrice != "" {
+		summary += " @ " + limitPrice
+	}
+	return summary
 }
 
-func getTradeType(arg string) string {
-	if arg == ArgMarket {
-		return TradeTypeMarket
+func getTimeInForce(arg string) (string, error) {
+	switch strings.ToLower(arg) {
+	case ArgGtc:
+		return TimeInForceGTC, nil
+	case ArgIoc:
+		return TimeInForceIOC, nil
+	case ArgFok:
+		return TimeInForceFOK, nil
 	}
-	return TradeTypeLimit
+	return "", fmt.Errorf("invalid time in force %q, expected gtc, ioc or fok", arg)
 }
 
-func getTradeSide(arg string) string {
-	if arg == ArgBuy {
-		return TradeSideBuy
+func getTradeType(arg string) (string, error) {
+	switch strings.ToLower(arg) {
+	case ArgMarket:
+		return TradeTypeMarket, nil
+	case ArgLimit:
+		return TradeTypeLimit, nil
 	}
-	return TradeSideSell
+	return "", fmt.Errorf("invalid order type %q, expected mkt or lim", arg)
 }
 
-func (app *TradeApp) ConstructTrade(params parsedTradeParams, limitPrice string, sessionId quickfix.SessionID) string {
-	msg, clOrdId := app.CreateHeader(app.PortfolioId, "D")
-	setTradeMessage(msg, params, limitPrice)
+func getTradeSide(arg string) (string, error) {
+	switch strings.ToLower(arg) {
+	case ArgBuy:
+		return TradeSideBuy, nil
+	case ArgSell:
+		return TradeSideSell, nil
+	}
+	return "", fmt.Errorf("invalid side %q, expected b or s", arg)
+}
 
+func (app *TradeApp) ConstructTrade(params parsedTradeParams, limitPrice string, sessionId quickfix.SessionID) (string, error) {
+	clOrdId, _, err := app.sendTrade(params, limitPrice, sessionId)
+	return clOrdId, err
+}
+
+// sendTrade submits a new order and returns a channel that receives the ExecType of its first execution report.
+func (app *TradeApp) sendTrade(params parsedTradeParams, limitPrice string, sessionId quickfix.SessionID) (string, <-chan string, error) {
+	if !app.IsLoggedOn() {
+		return "", nil, ErrNotConnected
+	}
+
+	msg, clOrdId := app.CreateHeader(app.PortfolioId, FixMsgNewOrder)
+	if err := setTradeMessage(msg, params, limitPrice, app.productIncrements(params.Product)); err != nil {
+		return "", nil, err
+	}
+
+	notional, ok := app.estimateNotional(params, limitPrice)
+	if !ok {
+		fmt.Printf(Yellow+"Warning: Unable to estimate notional for %s, order not counted toward the daily limit.\n"+Reset, params.Product)
+	}
+	if err := app.dailyNotional.Reserve(notional, app.dailyNotionalLimit(), time.Now()); err != nil {
+		return "", nil, err
+	}
+
+	acked := app.addPendingOrder(clOrdId, params)
 	if err := quickfix.SendToTarget(msg, sessionId); err != nil {
-		log.Printf("Error sending trade: %v", err)
+		app.removePendingOrder(clOrdId)
+		app.dailyNotional.Release(notional)
+		app.audit.Record("order_send_failed", LogFields{"cl_ord_id": clOrdId, "params": params, "limit_price": limitPrice, "fix": fixString(msg), "error": err})
+		return "", nil, fmt.Errorf("error sending trade: %w", err)
 	}
-	return clOrdId
+	app.balances.InvalidateProduct(params.Product)
+	app.audit.Record("order_submitted", LogFields{"cl_ord_id": clOrdId, "params": params, "limit_price": limitPrice, "fix": fixString(msg)})
+
+	promMetrics.orderSubmitted()
+	app.logger.Log(LogLevelInfo, "order_submitted", fmt.Sprintf("Order sent, ClOrdId: %s", clOrdId), LogFields{
+		"cl_ord_id":   clOrdId,
+		"product":     params.Product,
+		"side":        params.Side,
+		"order_type":  params.OrderType,
+		"quantity":    params.quantityString(),
+		"limit_price": limitPrice,
+	})
+	return clOrdId, acked, nil
 }
 
-func setTradeMessage(msg *quickfix.Message, params parsedTradeParams, limitPrice string) {
+func (app *TradeApp) logOrderFailure(params parsedTradeParams, err error) {
+	app.logger.Log(LogLevelError, "order_failed", fmt.Sprintf("Error: Order not sent: %v", err), LogFields{
+		"product":  params.Product,
+		"side":     params.Side,
+		"quantity": params.quantityString(),
+		"error":    err,
+	})
+}
+
+func (app *TradeApp) ConstructReplace(params replaceOrderParams, sessionId quickfix.SessionID) (string, error) {
+	if !app.IsLoggedOn() {
+		return "", ErrNotConnected
+	}
+
+	msg, clOrdId := app.CreateHeader(app.PortfolioId, FixMsgCancelReplace)
+	msg.Body.SetString(quickfix.Tag(FixTagOrderId), params.OrderId)
+	msg.Body.SetString(quickfix.Tag(FixTagOrigClOrdId), params.OrigClOrdId)
+	tradeParams := parsedTradeParams{
+		Product:      params.Product,
+		OrderType:    params.OrderType,
+		Side:         params.Side,
+		BaseQuantity: params.BaseQuantity,
+	}
+	if err := setTradeMessage(msg, tradeParams, params.LimitPrice, app.productIncrements(params.Product)); err != nil {
+		return "", err
+	}
+
+	if err := quickfix.SendToTarget(msg, sessionId); err != nil {
+		app.audit.Record("order_replace_failed", LogFields{"cl_ord_id": clOrdId, "params": params, "fix": fixString(msg), "error": err})
+		return "", fmt.Errorf("error sending replace: %w", err)
+	}
+	app.audit.Record("order_replace_submitted", LogFields{"cl_ord_id": clOrdId, "params": params, "fix": fixString(msg)})
+	return clOrdId, nil
+}
+
+func setTradeMessage(msg *quickfix.Message, params parsedTradeParams, limitPrice string, increments productIncrements) error {
+	if params.OrderType == TradeTypeLimit {
+		roundedPrice, err := roundToIncrement(limitPrice, increments.Quote, false)
+		if err != nil {
+			return fmt.Errorf("invalid limit price: %w", err)
+		}
+		limitPrice = roundedPrice
+	}
+
+	if params.StopPrice != "" {
+		roundedStop, err := roundToIncrement(params.StopPrice, increments.Quote, false)
+		if err != nil {
+			return fmt.Errorf("invalid stop price: %w", err)
+		}
+		params.StopPrice = roundedStop
+	}
+
 	msg.Body.SetString(quickfix.Tag(FixTagSymbol), params.Product)
-	setOrderType(msg, params.OrderType, limitPrice)
+	setOrderType(msg, params, limitPrice)
 	setSide(msg, params.Side)
-	setQuantity(msg, params.BaseQuantity)
+	if params.QuoteQuantity != "" {
+		return setCashQuantity(msg, params.QuoteQuantity, increments.Quote)
+	}
+	return setQuantity(msg, params.BaseQuantity, increments.Base)
 }
 
-func setOrderType(msg *quickfix.Message, orderType, limitPrice string) {
-	if orderType == TradeTypeMarket {
+func setOrderType(msg *quickfix.Message, params parsedTradeParams, limitPrice string) {
+	if params.OrderType == TradeTypeMarket {
 		msg.Body.SetString(quickfix.Tag(FixTagOrdType), FixOrdTypeMarket)
 		msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceIOC)
 		msg.Body.SetString(quickfix.Tag(FixTagExecInst), FixExecInstMarket)
-	} else if orderType == TradeTypeLimit {
+	} else if params.OrderType == TradeTypeLimit {
 		msg.Body.SetString(quickfix.Tag(FixTagOrdType), FixOrdTypeLimit)
-		msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceGTC)
+		switch {
+		case params.TimeInForce == TimeInForceIOC:
+			msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceIOC)
+		case params.TimeInForce == TimeInForceFOK:
+			msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceFOK)
+		case !params.ExpireTime.IsZero():
+			msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceGTD)
+			msg.Body.SetField(quickfix.Tag(FixTagExpireTime), quickfix.FIXUTCTimestamp{Time: params.ExpireTime})
+		default:
+			msg.Body.SetString(quickfix.Tag(FixTagTimeInForce), FixTimeInForceGTC)
+		}
 		msg.Body.SetString(quickfix.Tag(FixTagExecInst), FixExecInstLimit)
 		msg.Body.SetString(quickfix.Tag(FixTagPrice), limitPrice)
+		if params.StopPrice != "" {
+			msg.Body.SetString(quickfix.Tag(FixTagOrdType), FixOrdTypeStopLimit)
+			msg.Body.SetString(quickfix.Tag(FixTagStopPx), params.StopPrice)
+		}
 	}
 }
 
@@ -235,12 +781,42 @@ func setSide(msg *quickfix.Message, side string) {
 	}
 }
 
-func setQuantity(msg *quickfix.Message, baseQuantity string) {
-	quantity, err := strconv.ParseFloat(baseQuantity, 64)
+func setQuantity(msg *quickfix.Message, baseQuantity string, increment decimal.Decimal) error {
+	quantity, err := roundToIncrement(baseQuantity, increment, true)
 	if err != nil {
-		log.Printf("Error parsing quantity: %v", err)
-		return
+		return fmt.Errorf("invalid quantity: %w", err)
+	}
+	msg.Body.SetString(quickfix.Tag(FixTagOrderQty), quantity)
+	return nil
+}
+
+func setCashQuantity(msg *quickfix.Message, quoteQuantity string, increment decimal.Decimal) error {
+	quantity, err := roundToIncrement(quoteQuantity, increment, true)
+	if err != nil {
+		return fmt.Errorf("invalid quote quantity: %w", err)
+	}
+	msg.Body.SetString(quickfix.Tag(FixTagCashOrderQty), quantity)
+	return nil
+}
+
+func roundToIncrement(value string, increment decimal.Decimal, roundDown bool) (string, error) {
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return "", err
+	}
+
+	if increment.IsPositive() {
+		steps := parsed.Div(increment)
+		if roundDown {
+			steps = steps.Floor()
+		} else {
+			steps = steps.Round(0)
+		}
+		parsed = steps.Mul(increment)
+	}
+
+	if !parsed.IsPositive() {
+		return "", fmt.Errorf("%s rounds to zero at increment %s", value, increment.String())
 	}
-	quantityStr := fmt.Sprintf("%f", quantity)
-	msg.Body.SetString(quickfix.Tag(FixTagOrderQty), quantityStr)
+	return parsed.String(), nil
 }
@@ -0,0 +1,85 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       parsedTradeParams
+		limitPrice string
+		wantErr    bool
+	}{
+		{
+			name:  "market base quantity",
+			input: "eth-usd mkt b 0.001",
+			want:  parsedTradeParams{Product: "ETH-USD", OrderType: TradeTypeMarket, Side: TradeSideBuy, BaseQuantity: "0.001"},
+		},
+		{
+			name:  "market quote quantity",
+			input: "eth-usd mkt s $500",
+			want:  parsedTradeParams{Product: "ETH-USD", OrderType: TradeTypeMarket, Side: TradeSideSell, QuoteQuantity: "500"},
+		},
+		{
+			name:       "limit defaults time in force",
+			input:      "ETH-USD LIM B 1400 0.001",
+			want:       parsedTradeParams{Product: "ETH-USD", OrderType: TradeTypeLimit, Side: TradeSideBuy, BaseQuantity: "0.001"},
+			limitPrice: "1400",
+		},
+		{
+			name:       "limit with fok",
+			input:      "ltc-usd lim s 100 15 fok",
+			want:       parsedTradeParams{Product: "LTC-USD", OrderType: TradeTypeLimit, Side: TradeSideSell, BaseQuantity: "15", TimeInForce: TimeInForceFOK},
+			limitPrice: "100",
+		},
+		{name: "too few arguments", input: "eth-usd mkt b", wantErr: true},
+		{name: "unknown order type", input: "eth-usd stp b 0.001", wantErr: true},
+		{name: "unknown side", input: "eth-usd mkt x 0.001", wantErr: true},
+		{name: "malformed product", input: "ethusd mkt b 0.001", wantErr: true},
+		{name: "market with limit price", input: "eth-usd mkt b 1400 0.001", wantErr: true},
+		{name: "limit missing quantity", input: "eth-usd lim b 1400", wantErr: true},
+		{name: "limit with quote quantity", input: "eth-usd lim b 1400 $500", wantErr: true},
+		{name: "limit with bad time in force", input: "eth-usd lim b 1400 0.001 day", wantErr: true},
+		{name: "limit with extra argument", input: "eth-usd lim b 1400 0.001 gtc extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, limitPrice, err := parseArgs(strings.Fields(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseArgs(%q) = %+v, want error", tt.input, params)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs(%q) returned %v", tt.input, err)
+			}
+			if params != tt.want {
+				t.Errorf("parseArgs(%q) params = %+v, want %+v", tt.input, params, tt.want)
+			}
+			if limitPrice != tt.limitPrice {
+				t.Errorf("parseArgs(%q) limit price = %q, want %q", tt.input, limitPrice, tt.limitPrice)
+			}
+		})
+	}
+}
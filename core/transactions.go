@@ -0,0 +1,117 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type Transaction struct {
+	Id                string `json:"id"`
+	Type              string `json:"type"`
+	Status            string `json:"status"`
+	Symbol            string `json:"symbol"`
+	DestinationSymbol string `json:"destination_symbol"`
+	Amount            string `json:"amount"`
+	CreatedAt         string `json:"created_at"`
+	CompletedAt       string `json:"completed_at"`
+}
+
+type TransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Pagination   struct {
+		NextCursor string `json:"next_cursor"`
+		HasNext    bool   `json:"has_next"`
+	} `json:"pagination"`
+}
+
+func (app *TradeApp) fetchTransactionsPage(cursor string) ([]Transaction, string, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/transactions", app.PortfolioId)
+	queryParams := fmt.Sprintf("limit=%d", app.ordersPageSize())
+	if cursor != "" {
+		queryParams += "&cursor=" + url.QueryEscape(cursor)
+	}
+
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, queryParams, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	var response TransactionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if response.Pagination.HasNext {
+		nextCursor = response.Pagination.NextCursor
+	}
+	return response.Transactions, nextCursor, nil
+}
+
+func (app *TradeApp) ViewTransactions(reader *bufio.Reader) error {
+	cursor := ""
+	for {
+		transactions, nextCursor, err := app.fetchTransactionsPage(cursor)
+		if err != nil {
+			return err
+		}
+		if app.jsonOutput() {
+			if transactions == nil {
+				transactions = []Transaction{}
+			}
+			return writeJson(transactions)
+		}
+
+		app.printTransactionsTable(transactions)
+		if nextCursor == "" {
+			return nil
+		}
+
+		fmt.Printf("Type '%s' for the next page or 'x' to return to previous menu: ", SelectNextPage)
+		input, _ := reader.ReadString('\n')
+		if strings.TrimSpace(input) != SelectNextPage {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
+func (app *TradeApp) printTransactionsTable(transactions []Transaction) {
+	if len(transactions) == 0 {
+		fmt.Println("No transactions found!")
+		return
+	}
+
+	fmt.Printf(Blue+"%-3s| %-22s| %-11s| %-26s| %20s | %-20s\n"+Reset, "#", "Type", "Asset", "Status", "Amount", "Created")
+	for i, transaction := range transactions {
+		asset := valueOrX(transaction.Symbol)
+		if transaction.DestinationSymbol != "" && transaction.DestinationSymbol != transaction.Symbol {
+			asset += ">" + transaction.DestinationSymbol
+		}
+		amount := transaction.Amount
+		if app.isFiat(transaction.Symbol) {
+			amount = formatFiat(amount)
+		}
+		fmt.Printf(Blue+"%-3d| %-22s| %-11s| %-26s| %20s | %-20s\n"+Reset, i+1, valueOrX(transaction.Type), asset, valueOrX(transaction.Status),
+			valueOrX(amount), app.formatTimestamp(transaction.CreatedAt))
+	}
+}
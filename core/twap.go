@@ -0,0 +1,112 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (app *TradeApp) SubmitTwap(params parsedTradeParams, totalQty decimal.Decimal, slices int, interval time.Duration) (int, error) {
+	if params.OrderType != TradeTypeMarket {
+		return 0, fmt.Errorf("TWAP only supports market (mkt) orders")
+	}
+	if slices < 1 {
+		return 0, fmt.Errorf("slices must be at least 1")
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive")
+	}
+
+	childQty := totalQty.Div(decimal.NewFromInt(int64(slices))).Truncate(8)
+	if !childQty.IsPositive() {
+		return 0, fmt.Errorf("quantity %s is too small to split into %d slices", totalQty.String(), slices)
+	}
+
+	ctx, cancel := context.WithCancel(app.ctx)
+	app.twapMutex.Lock()
+	app.nextTwapId++
+	id := app.nextTwapId
+	app.twapSchedules[id] = cancel
+	app.twapMutex.Unlock()
+
+	go app.runTwap(ctx, id, params, totalQty, childQty, slices, interval)
+	return id, nil
+}
+
+func (app *TradeApp) runTwap(ctx context.Context, id int, params parsedTradeParams, totalQty, childQty decimal.Decimal, slices int, interval time.Duration) {
+	defer app.finishTwap(id)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	remaining := totalQty
+	for slice := 1; slice <= slices; slice++ {
+		qty := childQty
+		if slice == slices {
+			qty = remaining
+		}
+
+		child := params
+		child.BaseQuantity = qty.String()
+		if app.validateOrderAgainstFFP(child, "") {
+			if _, err := app.ConstructTrade(child, "", app.SessionId); err != nil {
+				app.logOrderFailure(child, err)
+			} else {
+				remaining = remaining.Sub(qty)
+			}
+		} else {
+			fmt.Printf(Yellow+"TWAP #%d: slice %d/%d skipped by fat finger protection\n"+Reset, id, slice, slices)
+		}
+		fmt.Printf(Blue+"TWAP #%d: slice %d/%d done, %s %s remaining\n"+Reset, id, slice, slices, remaining.String(), params.Product)
+
+		if slice == slices {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Printf(Yellow+"TWAP #%d canceled with %s %s unsent\n"+Reset, id, remaining.String(), params.Product)
+			return
+		case <-ticker.C:
+		}
+	}
+	fmt.Printf(Green+"TWAP #%d complete\n"+Reset, id)
+}
+
+func (app *TradeApp) finishTwap(id int) {
+	app.twapMutex.Lock()
+	defer app.twapMutex.Unlock()
+	if cancel, ok := app.twapSchedules[id]; ok {
+		cancel()
+		delete(app.twapSchedules, id)
+	}
+}
+
+func (app *TradeApp) CancelTwaps() int {
+	app.twapMutex.Lock()
+	defer app.twapMutex.Unlock()
+
+	canceled := len(app.twapSchedules)
+	for id, cancel := range app.twapSchedules {
+		cancel()
+		delete(app.twapSchedules, id)
+	}
+	return canceled
+}
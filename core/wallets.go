@@ -0,0 +1,178 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var walletTypes = []string{"TRADING", "VAULT"}
+
+type Wallet struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Symbol    string `json:"symbol"`
+	Type      string `json:"type"`
+	CreatedAt string `json:"created_at"`
+}
+
+type WalletsResponse struct {
+	Wallets    []Wallet `json:"wallets"`
+	Pagination struct {
+		NextCursor string `json:"next_cursor"`
+		HasNext    bool   `json:"has_next"`
+	} `json:"pagination"`
+}
+
+type WalletBalanceResponse struct {
+	Balance Balance `json:"balance"`
+}
+
+type DepositInstructionsResponse struct {
+	CryptoInstructions struct {
+		Address           string `json:"address"`
+		AccountIdentifier string `json:"account_identifier"`
+	} `json:"crypto_instructions"`
+}
+
+func (app *TradeApp) GetWallets() ([]Wallet, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/wallets", app.PortfolioId)
+	var wallets []Wallet
+	for _, walletType := range walletTypes {
+		cursor := ""
+		for {
+			queryParams := fmt.Sprintf("type=%s&limit=%d", walletType, app.ordersPageSize())
+			if cursor != "" {
+				queryParams += "&cursor=" + url.QueryEscape(cursor)
+			}
+
+			body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, queryParams, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s wallets: %w", strings.ToLower(walletType), err)
+			}
+
+			var response WalletsResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				return nil, err
+			}
+			wallets = append(wallets, response.Wallets...)
+
+			if !response.Pagination.HasNext || response.Pagination.NextCursor == "" {
+				break
+			}
+			cursor = response.Pagination.NextCursor
+		}
+	}
+	return wallets, nil
+}
+
+func (app *TradeApp) GetWalletBalance(walletId string) (Balance, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/wallets/%s/balance", app.PortfolioId, url.PathEscape(walletId))
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "", nil)
+	if err != nil {
+		return Balance{}, fmt.Errorf("failed to fetch wallet balance: %w", err)
+	}
+
+	var response WalletBalanceResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Balance{}, err
+	}
+	return response.Balance, nil
+}
+
+func (app *TradeApp) GetWalletAddress(walletId string) (string, error) {
+	path := fmt.Sprintf("/v1/portfolios/%s/wallets/%s/deposit_instructions", app.PortfolioId, url.PathEscape(walletId))
+	body, err := app.makeAuthenticatedRequest(app.ctx, "GET", path, "deposit_type=CRYPTO", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch wallet address: %w", err)
+	}
+
+	var response DepositInstructionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	return response.CryptoInstructions.Address, nil
+}
+
+func (app *TradeApp) ViewWallets(reader *bufio.Reader) error {
+	wallets, err := app.GetWallets()
+	if err != nil {
+		return err
+	}
+	if app.jsonOutput() {
+		if wallets == nil {
+			wallets = []Wallet{}
+		}
+		return writeJson(wallets)
+	}
+
+	if len(wallets) == 0 {
+		fmt.Println("No wallets found!")
+		return nil
+	}
+
+	for {
+		fmt.Println(Blue + "#  | Name                         | Asset  | Type     | Id" + Reset)
+		for i, wallet := range wallets {
+			fmt.Printf(Blue+"%-3d| %-29s| %-7s| %-9s| %s\n"+Reset, i+1, valueOrX(wallet.Name), valueOrX(wallet.Symbol), valueOrX(wallet.Type), wallet.Id)
+		}
+
+		fmt.Print("Select a wallet by number to view its balance and address, or type 'x' to return to previous menu: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		input = strings.TrimSpace(input)
+		if input == SelectExit {
+			return nil
+		}
+
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice <= 0 || choice > len(wallets) {
+			fmt.Println("Invalid choice. Please select again.")
+			continue
+		}
+		app.printWallet(wallets[choice-1])
+	}
+}
+
+func (app *TradeApp) printWallet(wallet Wallet) {
+	balance, err := app.GetWalletBalance(wallet.Id)
+	if err != nil {
+		fmt.Println("Error fetching balance:", err)
+		return
+	}
+	if app.isFiat(wallet.Symbol) {
+		balance = formatFiatBalance(balance)
+	}
+	fmt.Printf(Blue+"Wallet: %s (%s)\nAmount: %s\nHolds: %s\nWithdrawable Amount: %s\nFiat Amount: %s\n"+Reset, valueOrX(wallet.Name), wallet.Id, balance.Amount, balance.Holds, balance.WithdrawableAmount, balance.FiatAmount)
+
+	if app.isFiat(wallet.Symbol) {
+		return
+	}
+	address, err := app.GetWalletAddress(wallet.Id)
+	if err != nil {
+		fmt.Println("Error fetching address:", err)
+		return
+	}
+	fmt.Printf(Blue+"Address: %s\n"+Reset, valueOrX(address))
+}
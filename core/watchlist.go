@@ -0,0 +1,237 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultWatchlistRefresh = 5 * time.Second
+	watchlistConfigKey      = "Watchlist"
+)
+
+var credentialsFilePath = credsFile
+
+func (app *TradeApp) watchlistRefreshInterval() time.Duration {
+	if app.WatchRefreshSeconds <= 0 {
+		return DefaultWatchlistRefresh
+	}
+	return time.Duration(app.WatchRefreshSeconds) * time.Second
+}
+
+func (app *TradeApp) watchlistMode(reader *bufio.Reader) {
+	for {
+		fmt.Println(LineSpacer)
+		if len(app.Watchlist) == 0 {
+			fmt.Println("Watchlist is empty.")
+		} else {
+			fmt.Printf(Blue+"Watchlist: %s\n"+Reset, strings.Join(app.Watchlist, ", "))
+		}
+		fmt.Printf("Type '%s product' or '%s product' to edit the watchlist, '%s' to watch prices, or '%s' to return to main menu:\n", ArgAdd, ArgRemove, SelectWatch, SelectExit)
+
+		input, err := GetUserInput(reader)
+		if err != nil {
+			fmt.Println("Error reading input:", err)
+			continue
+		}
+
+		parts := strings.Fields(strings.ToLower(input))
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case SelectExit:
+			return
+		case SelectWatch:
+			if len(app.Watchlist) == 0 {
+				fmt.Println("Add a product before watching.")
+				continue
+			}
+			if err := app.WatchPrices(reader); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case ArgAdd, ArgRemove:
+			if len(parts) != 2 {
+				fmt.Printf("Usage: %s product\n", parts[0])
+				continue
+			}
+			if err := app.editWatchlist(parts[0], parts[1]); err != nil {
+				fmt.Println("Error:", err)
+			}
+		default:
+			fmt.Println("Invalid choice. Please select again.")
+		}
+	}
+}
+
+func (app *TradeApp) editWatchlist(action, product string) error {
+	product, err := normalizeProduct(product)
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, existing := range app.Watchlist {
+		if existing == product {
+			index = i
+			break
+		}
+	}
+
+	watchlist := append([]string{}, app.Watchlist...)
+	switch action {
+	case ArgAdd:
+		if index != -1 {
+			return fmt.Errorf("%s is already on the watchlist", product)
+		}
+		watchlist = append(watchlist, product)
+	case ArgRemove:
+		if index == -1 {
+			return fmt.Errorf("%s is not on the watchlist", product)
+		}
+		watchlist = append(watchlist[:index], watchlist[index+1:]...)
+	}
+
+	app.Watchlist = watchlist
+	if err := saveWatchlist(credentialsFilePath, watchlist); err != nil {
+		return fmt.Errorf("watchlist updated for this session but not saved: %w", err)
+	}
+	return nil
+}
+
+// saveWatchlist rewrites only the Watchlist key of the credentials file, leaving the other settings as written.
+func saveWatchlist(path string, watchlist []string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if watchlist == nil {
+		watchlist = []string{}
+	}
+	if settings[watchlistConfigKey], err = json.Marshal(watchlist); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), info.Mode().Perm())
+}
+
+type watchlistResult struct {
+	errs []error
+}
+
+func (app *TradeApp) WatchPrices(reader *bufio.Reader) error {
+	ctx, cancel := context.WithCancel(app.ctx)
+	defer cancel()
+
+	exitCh := make(chan struct{})
+	go readExitInput(reader, exitCh)
+
+	products := append([]string{}, app.Watchlist...)
+	results := make(chan watchlistResult, 1)
+	inFlight := false
+	poll := func() {
+		inFlight = true
+		go func() {
+			var result watchlistResult
+			for _, product := range products {
+				if ctx.Err() != nil {
+					return
+				}
+				if _, err := app.fetchPrice(product); err != nil {
+					result.errs = append(result.errs, err)
+				}
+			}
+			results <- result
+		}()
+	}
+
+	interval := app.watchlistRefreshInterval()
+	fmt.Printf("Refreshing watchlist every %s. Type '%s' to stop.\n", interval, SelectExit)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lines := 0
+	poll()
+	for {
+		select {
+		case <-exitCh:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if !inFlight {
+				poll()
+			}
+		case result := <-results:
+			inFlight = false
+			if lines > 0 {
+				fmt.Printf("\033[%dA\033[J", lines)
+			}
+			lines = app.printWatchlist(products, time.Now())
+			for _, err := range result.errs {
+				fmt.Printf(Red+"Error: %v\n"+Reset, err)
+			}
+			lines += len(result.errs)
+		}
+	}
+}
+
+func (app *TradeApp) printWatchlist(products []string, now time.Time) int {
+	fmt.Println(Blue + "Product    |          Bid |          Ask |         Last |    Age" + Reset)
+	for _, product := range products {
+		priceData, ok := app.prices.Get(product)
+		if !ok {
+			fmt.Printf("%-11s| %12s | %12s | %12s | %6s\n", product, "-", "-", "-", "-")
+			continue
+		}
+		fmt.Printf("%-11s| %12s | %12s | %12s | %6s\n", product, watchlistPrice(priceData.Bid), watchlistPrice(priceData.Ask),
+			watchlistPrice(priceData.Price), now.Sub(priceData.FetchedAt).Round(time.Second))
+	}
+	fmt.Printf("Last updated %s\n", now.Format("15:04:05"))
+	return len(products) + 2
+}
+
+func watchlistPrice(value string) string {
+	price, err := decimal.NewFromString(value)
+	if err != nil {
+		return "-"
+	}
+	return price.String()
+}
@@ -18,121 +18,477 @@ package core
 
 import (
 	"bufio"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"os"
+	"math/rand"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
 const (
-	Uri       = "wss://ws-feed.prime.coinbase.com"
-	ChannelL2 = "l2_data"
+	Uri                  = "wss://ws-feed.prime.coinbase.com"
+	ChannelL2            = "l2_data"
+	ChannelSubscriptions = "subscriptions"
+	ChannelHeartbeats    = "heartbeats"
+	L2EventSnapshot      = "snapshot"
+	L2EventUpdate        = "update"
+	WsTypeError          = "error"
+
+	ReconnectBaseDelay = time.Second
+	ReconnectMaxDelay  = 30 * time.Second
+	WsReadTimeout      = 10 * time.Second
 )
 
-func (app *TradeApp) StartWebSocket(productId string, n int) {
-	app.disconnect = false
-	log.Println("Type 'x' to disconnect.")
+type reconnectBackoff struct {
+	attempt int
+	base    time.Duration
+	max     time.Duration
+}
+
+func newReconnectBackoff() *reconnectBackoff {
+	return &reconnectBackoff{base: ReconnectBaseDelay, max: ReconnectMaxDelay}
+}
+
+func (b *reconnectBackoff) Next() time.Duration {
+	delay := b.max
+	if b.attempt < 30 && b.base<<b.attempt < b.max {
+		delay = b.base << b.attempt
+	}
+	b.attempt++
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	if delay+jitter > b.max {
+		return b.max
+	}
+	return delay + jitter
+}
+
+func (b *reconnectBackoff) Reset() {
+	b.attempt = 0
+}
+
+var (
+	ErrSequenceGap = errors.New("sequence gap detected")
+	ErrReadTimeout = errors.New("websocket read timed out")
+)
+
+// wsServerError is an error frame sent by Coinbase, e.g. for a bad signature or unknown product.
+// Retrying the same subscription will not help, so the stream stops instead of reconnecting.
+type wsServerError struct {
+	Message string
+}
+
+func (e *wsServerError) Error() string {
+	lower := strings.ToLower(e.Message)
+	for _, hint := range []string{"auth", "signature", "key", "passphrase", "timestamp"} {
+		if strings.Contains(lower, hint) {
+			return fmt.Sprintf("websocket authentication rejected: %s. Check ApiKey, ApiSecret, Passphrase and your system clock", e.Message)
+		}
+	}
+	return "websocket subscription rejected: " + e.Message
+}
+
+func checkWsError(envelope wsEnvelope, response []byte) error {
+	if envelope.Type != WsTypeError {
+		return nil
+	}
+	message := envelope.Message
+	if message == "" {
+		message = string(response)
+	}
+	return &wsServerError{Message: message}
+}
+
+func isPermanentWsError(err error) bool {
+	var serverErr *wsServerError
+	if errors.As(err, &serverErr) {
+		return true
+	}
+	return websocket.IsCloseError(err, websocket.ClosePolicyViolation)
+}
+
+type sequenceTracker struct {
+	last int64
+	seen bool
+}
+
+func (t *sequenceTracker) Check(sequence int64) error {
+	if t.seen && sequence != t.last+1 {
+		expected := t.last + 1
+		t.seen = false
+		return fmt.Errorf("%w: expected %d, got %d", ErrSequenceGap, expected, sequence)
+	}
+	t.last = sequence
+	t.seen = true
+	return nil
+}
+
+// InterruptStream stops a running market data stream and reports whether one was running, so
+// an interrupt signal can return to the menu instead of exiting the shell.
+func (app *TradeApp) InterruptStream() bool {
+	app.wsMutex.Lock()
+	stop := app.stopStream
+	app.wsMutex.Unlock()
+	if stop == nil {
+		return false
+	}
+	stop()
+	return true
+}
 
+// interruptRead expires the read deadline on the open connection so a ReadMessage blocked on a
+// quiet feed returns at once and the stream loop sees that it was stopped.
+func (app *TradeApp) interruptRead() {
+	app.wsMutex.Lock()
+	defer app.wsMutex.Unlock()
+	if app.wsConn != nil {
+		app.wsConn.SetReadDeadline(time.Now())
+	}
+}
+
+func (app *TradeApp) setStopStream(stop func()) {
+	app.wsMutex.Lock()
+	app.stopStream = stop
+	app.wsMutex.Unlock()
+}
+
+func (app *TradeApp) StartWebSocket(productIds []string, n int, reader *bufio.Reader, recordPath string) {
+	var recorder *feedRecorder
+	if recordPath != "" {
+		var err error
+		if recorder, err = openFeedRecorder(recordPath); err != nil {
+			fmt.Printf(Red+"Error opening recording file: %v\n"+Reset, err)
+			return
+		}
+		defer recorder.Close()
+		log.Printf("Recording raw feed to %s", recordPath)
+	}
+
+	app.vwapQuery = nil
+	log.Println("Type 'x' or press Ctrl-C to disconnect, or 'vwap b/s size' to estimate a sweep price.")
+
+	exitCh := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(exitCh)
+			app.interruptRead()
+		})
+	}
+	app.setStopStream(stop)
+	defer app.setStopStream(nil)
+
+	vwapCh := make(chan *vwapQuery, 1)
+	inputDone := make(chan struct{})
+	go readMarketDataInput(reader, exitCh, stop, vwapCh, inputDone)
+	defer app.awaitStreamInput(inputDone)
+
+	backoff := newReconnectBackoff()
 	for {
-		doneCh := make(chan struct{})
-		if err := app.mainLoop(productId, doneCh, n); err != nil {
-			<-doneCh
-			if app.disconnect {
+		err := app.mainLoop(productIds, n, exitCh, vwapCh, backoff, recorder)
+		select {
+		case <-exitCh:
+			app.FirstPrint = true
+			return
+		case <-app.ctx.Done():
+			return
+		default:
+		}
+
+		if isPermanentWsError(err) {
+			log.Printf(Red+"Error: %v. Type 'x' or press Ctrl-C to return."+Reset, err)
+			select {
+			case <-exitCh:
 				app.FirstPrint = true
-				return
+			case <-app.ctx.Done():
 			}
-			log.Printf(Red+"Error: %v. Retrying in 5 seconds..."+Reset, err)
-			time.Sleep(5 * time.Second)
-		} else {
-			if app.disconnect {
-				app.FirstPrint = true
-				break
+			return
+		}
+
+		promMetrics.websocketReconnect()
+		delay := backoff.Next()
+		log.Printf(Red+"Error: %v. Retrying in %s..."+Reset, err, delay.Round(time.Millisecond))
+		select {
+		case <-exitCh:
+			app.FirstPrint = true
+			return
+		case <-app.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// readMarketDataInput owns the shared reader until it returns, which it only does after
+// consuming a line, so the menu never races it for input.
+func readMarketDataInput(reader *bufio.Reader, exitCh chan struct{}, stop func(), vwapCh chan *vwapQuery, done chan struct{}) {
+	defer close(done)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				log.Printf(Red+"Input error: %v"+Reset, err)
+			}
+			stop()
+			return
+		}
+
+		select {
+		case <-exitCh:
+			return
+		default:
+		}
+
+		input := strings.TrimSpace(line)
+		if input == SelectExit {
+			stop()
+			return
+		}
+		if query, err := parseVwapCommand(input); err == nil {
+			select {
+			case vwapCh <- query:
+			default:
 			}
 		}
 	}
 }
 
-func (app *TradeApp) mainLoop(productId string, doneCh chan struct{}, n int) error {
-	defer close(doneCh)
+func (app *TradeApp) awaitStreamInput(done chan struct{}) {
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	fmt.Println("Stream stopped, press Enter to return to the menu.")
+	select {
+	case <-done:
+	case <-app.ctx.Done():
+	}
+}
 
-	c, _, err := websocket.DefaultDialer.Dial(Uri, nil)
+func (app *TradeApp) dialL2(productIds []string) (*websocket.Conn, error) {
+	c, _, err := app.wsDialer.Dial(app.webSocketUri(), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer c.Close()
 
-	authMessage, err := app.createAuthMessage(productId)
+	authMessage, err := app.createAuthMessage(productIds)
 	if err != nil {
-		return err
+		c.Close()
+		return nil, err
 	}
 
 	if err = c.WriteMessage(websocket.TextMessage, authMessage); err != nil {
-		return err
+		c.Close()
+		return nil, err
 	}
 
-	exitCh := make(chan struct{})
-	continueLoop := true
-
-	go func() {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			input := scanner.Text()
-			if input == SelectExit {
-				app.disconnect = true
-				close(exitCh)
-				return
-			}
-		}
-		if err := scanner.Err(); err != nil {
-			log.Printf(Red+"Scanner error: %v"+Reset, err)
-		}
-	}()
+	if err = c.SetReadDeadline(time.Now().Add(app.wsReadTimeout())); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	app.wsMutex.Lock()
+	app.wsConn = c
+	app.wsMutex.Unlock()
+	return c, nil
+}
+
+func (app *TradeApp) wsReadTimeout() time.Duration {
+	if app.WsReadTimeoutSeconds <= 0 {
+		return WsReadTimeout
+	}
+	return time.Duration(app.WsReadTimeoutSeconds) * time.Second
+}
+
+func (app *TradeApp) webSocketUri() string {
+	if app.WebSocketUri == "" {
+		return Uri
+	}
+	return app.WebSocketUri
+}
+
+func (app *TradeApp) closeWebSocket() {
+	app.wsMutex.Lock()
+	defer app.wsMutex.Unlock()
+	if app.wsConn == nil {
+		return
+	}
 
-	isFirstMessage := true
-	for continueLoop {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	app.wsConn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+	app.wsConn.Close()
+	app.wsConn = nil
+}
+
+func (app *TradeApp) mainLoop(productIds []string, n int, exitCh chan struct{}, vwapCh chan *vwapQuery, backoff *reconnectBackoff, recorder *feedRecorder) error {
+	c, err := app.dialL2(productIds)
+	if err != nil {
+		return err
+	}
+	defer app.closeWebSocket()
+
+	app.OrderBooks = make(map[string]*OrderBookProcessor)
+	app.feedStats = &feedStats{}
+	sequence := &sequenceTracker{}
+	for {
 		select {
 		case <-exitCh:
-			if err := c.Close(); err != nil {
-				log.Println("Failed to close WebSocket:", err)
-			} else {
-				log.Println("WebSocket closed successfully")
-			}
-			continueLoop = false
+			app.closeWebSocket()
+			log.Println("WebSocket closed successfully")
+			return nil
+
+		case <-app.ctx.Done():
+			return app.ctx.Err()
+
+		case query := <-vwapCh:
+			app.vwapQuery = query
 
 		default:
 			messageType, response, err := c.ReadMessage()
 			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					return fmt.Errorf("%w: no message received in %s", ErrReadTimeout, app.wsReadTimeout())
+				}
 				log.Println("Failed to read WebSocket message:", err)
 				return err
 			}
-			c.SetReadDeadline(time.Now().Add(10 * time.Second))
+			c.SetReadDeadline(time.Now().Add(app.wsReadTimeout()))
+			backoff.Reset()
 
 			if messageType == websocket.TextMessage {
-				if isFirstMessage {
-					isFirstMessage = false
-					app.OrderBook = NewOrderBookProcessor(string(response))
-				} else {
-					app.OrderBook.ApplyUpdate(string(response))
+				recorder.Record(time.Now(), response)
+				envelope, err := parseWsEnvelope(string(response))
+				if err != nil {
+					log.Printf("Failed to parse WebSocket message: %v", err)
+					continue
+				}
+				if err := checkWsError(envelope, response); err != nil {
+					return err
+				}
+
+				if envelope.SequenceNum != nil {
+					if err := sequence.Check(*envelope.SequenceNum); err != nil {
+						log.Printf(Red+"%v, resubscribing for a fresh snapshot"+Reset, err)
+						app.OrderBooks = make(map[string]*OrderBookProcessor)
+						return err
+					}
+				}
+
+				switch envelope.Channel {
+				case ChannelSubscriptions:
+					log.Printf("Subscription confirmed for %s", strings.Join(productIds, ", "))
+					continue
+				case ChannelHeartbeats:
+					app.debugf("Heartbeat received: %s", response)
+					continue
+				case ChannelL2:
+				default:
+					app.debugf("Ignoring message on channel %q (type %q): %s", envelope.Channel, envelope.Type, response)
+					continue
 				}
-				displayOrderBook(app, app.OrderBook, n)
+
+				if !app.applyL2Message(app.OrderBooks, envelope, string(response)) {
+					continue
+				}
+				app.feedStats.record(time.Now(), envelope.Timestamp)
+				displayOrderBooks(app, app.OrderBooks, app.feedStats, app.vwapQuery, productIds, n)
 			}
 			time.Sleep(10 * time.Millisecond)
 		}
 	}
+}
+
+func (app *TradeApp) SnapshotOrderBooks(productIds []string, n int) error {
+	c, err := app.dialL2(productIds)
+	if err != nil {
+		return err
+	}
+	defer app.closeWebSocket()
+
+	app.vwapQuery = nil
+	app.feedStats = nil
+	app.OrderBooks = make(map[string]*OrderBookProcessor)
+	for len(app.OrderBooks) < len(productIds) {
+		_, response, err := c.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return fmt.Errorf("%w: snapshot not received in %s", ErrReadTimeout, app.wsReadTimeout())
+			}
+			return err
+		}
+
+		envelope, err := parseWsEnvelope(string(response))
+		if err != nil {
+			log.Printf("Failed to parse WebSocket message: %v", err)
+			continue
+		}
+		if err := checkWsError(envelope, response); err != nil {
+			return err
+		}
+		if envelope.Channel != ChannelL2 || envelope.EventType != L2EventSnapshot {
+			continue
+		}
+		app.applyL2Message(app.OrderBooks, envelope, string(response))
+	}
+
+	for _, productId := range productIds {
+		fmt.Println(Cyan + productId + Reset)
+		displayOrderBook(app, app.OrderBooks[productId], n, app.bookPrecision(productId), nil)
+	}
 	return nil
 }
 
-func (app *TradeApp) createAuthMessage(productId string) ([]byte, error) {
+// applyL2Message loads a snapshot into books or applies an update to the product's existing book.
+func (app *TradeApp) applyL2Message(books map[string]*OrderBookProcessor, envelope wsEnvelope, message string) bool {
+	if envelope.ProductId == "" {
+		app.debugf("Ignoring l2_data message without a product: %s", message)
+		return false
+	}
+
+	var book *OrderBookProcessor
+	switch envelope.EventType {
+	case L2EventSnapshot:
+		var err error
+		book, err = NewOrderBookProcessor(message, app.bookMaxDepth())
+		if err != nil {
+			log.Printf(Red+"Error loading %s snapshot: %v"+Reset, envelope.ProductId, err)
+			return false
+		}
+		books[envelope.ProductId] = book
+	case L2EventUpdate:
+		var ok bool
+		book, ok = books[envelope.ProductId]
+		if !ok {
+			app.debugf("Ignoring update for %s received before its snapshot", envelope.ProductId)
+			return false
+		}
+		book.ApplyUpdate(message)
+	default:
+		app.debugf("Ignoring l2_data event type %q for %s", envelope.EventType, envelope.ProductId)
+		return false
+	}
+
+	if envelope.SequenceNum != nil {
+		book.LastSequence = *envelope.SequenceNum
+	}
+	return true
+}
+
+func (app *TradeApp) createAuthMessage(productIds []string) ([]byte, error) {
 	timestamp := fmt.Sprintf("%d", time.Now().Unix())
-	signature := wsSign(ChannelL2, app.ApiKey, app.ApiSecret, app.SvcAccountId, productId, timestamp)
+	signature := wsSign(ChannelL2, app.ApiKey, app.ApiSecret, app.SvcAccountId, strings.Join(productIds, ""), timestamp)
 
 	msg := map[string]interface{}{
 		"type":        "subscribe",
@@ -142,45 +498,115 @@ func (app *TradeApp) createAuthMessage(productId string) ([]byte, error) {
 		"timestamp":   timestamp,
 		"passphrase":  app.Passphrase,
 		"signature":   signature,
-		"product_ids": []string{productId},
+		"product_ids": productIds,
 	}
 
 	return json.Marshal(msg)
 }
 
 func wsSign(channel, key, secret, accountId, productId, timestamp string) string {
-	msg := channel + key + accountId + timestamp + productId
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(msg))
-	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return computeHMAC256(wsSignatureMessage(channel, key, accountId, timestamp, productId), secret)
+}
+
+// wsSignatureMessage builds the subscribe prehash: channel + access key + service account id + timestamp + joined product ids.
+func wsSignatureMessage(channel, key, accountId, timestamp, productId string) string {
+	return channel + key + accountId + timestamp + productId
 }
 
 func (app *TradeApp) MarketDataMode(reader *bufio.Reader) {
 	for {
-		fmt.Println("Enter product to subscribe to (format: asset1-asset2 n) where n is number of top bids/asks (1-9) or type 'x' to return to main menu:")
+		fmt.Printf("Enter products to subscribe to (format: asset1-asset2[,asset3-asset4] n [%s] [%s bucket] [%s file]) where n is number of top bids/asks (1-9), append '%s' to print a single snapshot, '%s 1' to group levels into $1 price buckets, '%s feed.jsonl' to record the raw feed, or type 'x' to return to main menu:\n", ArgSnapshot, ArgGroup, ArgRecord, ArgSnapshot, ArgGroup, ArgRecord)
 
 		input, _ := reader.ReadString('\n')
-		input = strings.ToUpper(strings.TrimSpace(input))
-		if input == SelectExitWs {
+		input = strings.TrimSpace(input)
+		if strings.ToUpper(input) == SelectExitWs {
 			return
 		}
 
-		parts := strings.Split(input, " ")
-		if len(parts) != 2 || !validateProductFormat(parts[0]) {
+		parts := strings.Fields(input)
+		if len(parts) < 2 {
 			fmt.Println("Invalid input format. Please try again.")
 			continue
 		}
 
-		product, nStr := parts[0], parts[1]
-		n, err := strconv.Atoi(nStr)
+		snapshotOnly, groupSize, validFlags := false, 0.0, true
+		recordPath := ""
+		for i := 2; i < len(parts); i++ {
+			switch strings.ToLower(parts[i]) {
+			case ArgSnapshot:
+				snapshotOnly = true
+			case ArgGroup:
+				if i+1 >= len(parts) {
+					validFlags = false
+					break
+				}
+				size, err := strconv.ParseFloat(parts[i+1], 64)
+				if err != nil || size <= 0 {
+					validFlags = false
+				}
+				groupSize = size
+				i++
+			case ArgRecord:
+				if i+1 >= len(parts) {
+					validFlags = false
+					break
+				}
+				recordPath = parts[i+1]
+				i++
+			default:
+				validFlags = false
+			}
+		}
+		if !validFlags || (snapshotOnly && recordPath != "") {
+			fmt.Println("Invalid input format. Please try again.")
+			continue
+		}
+
+		var products []string
+		seenProducts := make(map[string]bool)
+		validFormat := true
+		for _, product := range strings.Split(parts[0], ",") {
+			normalized, err := normalizeProduct(product)
+			if err != nil {
+				fmt.Printf(Red+"Error: %v\n"+Reset, err)
+				validFormat = false
+			}
+			// A repeated product gets a single book, so SnapshotOrderBooks would wait forever for a second one.
+			if seenProducts[normalized] {
+				continue
+			}
+			seenProducts[normalized] = true
+			products = append(products, normalized)
+		}
+		if !validFormat {
+			continue
+		}
+
+		n, err := strconv.Atoi(parts[1])
 		if err != nil || n < 1 || n > 9 {
 			fmt.Println("Invalid number of top bids/asks. Please enter a value between 1 and 9.")
 			continue
 		}
 
-		assetParts := strings.Split(product, "-")
-		if len(assetParts) > 0 {
-			asset := assetParts[0]
+		knownProducts := true
+		for _, product := range products {
+			if err := app.validateKnownProduct(product); err != nil {
+				fmt.Printf(Red+"Error: %v\n"+Reset, err)
+				knownProducts = false
+			}
+		}
+		if !knownProducts {
+			continue
+		}
+
+		seenAssets := make(map[string]bool)
+		for _, product := range products {
+			asset := strings.Split(product, "-")[0]
+			if seenAssets[asset] {
+				continue
+			}
+			seenAssets[asset] = true
+
 			balance, err := app.GetAssetBalance(asset)
 			if err != nil {
 				fmt.Printf("Error fetching balance for %s: %s\n", asset, err)
@@ -190,10 +616,37 @@ func (app *TradeApp) MarketDataMode(reader *bufio.Reader) {
 			}
 		}
 
-		app.StartWebSocket(product, n)
+		app.bookGroupSize = groupSize
+		if snapshotOnly {
+			if err := app.SnapshotOrderBooks(products, n); err != nil {
+				fmt.Printf(Red+"Error: %v\n"+Reset, err)
+			}
+			continue
+		}
+
+		app.StartWebSocket(products, n, reader, recordPath)
 	}
 }
 
-func validateProductFormat(product string) bool {
-	return len(strings.Split(product, "-")) == 2
+func parseVwapCommand(input string) (*vwapQuery, error) {
+	parts := strings.Fields(strings.ToLower(input))
+	if len(parts) != 3 || parts[0] != ArgVwap {
+		return nil, fmt.Errorf("expected format: vwap b/s size")
+	}
+
+	var side string
+	switch parts[1] {
+	case ArgBuy:
+		side = TradeSideBuy
+	case ArgSell:
+		side = TradeSideSell
+	default:
+		return nil, fmt.Errorf("invalid side: %s", parts[1])
+	}
+
+	size, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("invalid size: %s", parts[2])
+	}
+	return &vwapQuery{Side: side, Size: size}, nil
 }
@@ -0,0 +1,167 @@
+/*
+Copyright 2023-present Coinbase Global, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectBackoffGrowsAndCaps(t *testing.T) {
+	backoff := &reconnectBackoff{base: time.Second, max: 30 * time.Second}
+
+	expected := []time.Duration{1, 2, 4, 8, 16, 30, 30}
+	for i, want := range expected {
+		want *= time.Second
+		got := backoff.Next()
+		if got < want || got > want+want/5 || got > backoff.max {
+			t.Errorf("attempt %d: delay = %s, want between %s and %s capped at %s", i, got, want, want+want/5, backoff.max)
+		}
+	}
+}
+
+func TestReconnectBackoffReset(t *testing.T) {
+	backoff := newReconnectBackoff()
+	for i := 0; i < 5; i++ {
+		backoff.Next()
+	}
+
+	backoff.Reset()
+	if got := backoff.Next(); got < ReconnectBaseDelay || got > ReconnectBaseDelay+ReconnectBaseDelay/5 {
+		t.Errorf("delay after reset = %s, want about %s", got, ReconnectBaseDelay)
+	}
+}
+
+func TestReconnectBackoffLargeAttempt(t *testing.T) {
+	backoff := newReconnectBackoff()
+	backoff.attempt = 100
+	if got := backoff.Next(); got != ReconnectMaxDelay {
+		t.Errorf("delay = %s, want %s", got, ReconnectMaxDelay)
+	}
+}
+
+// newSilentWsServer accepts websocket connections, reads the subscribe message and then never
+// sends anything, counting each connection.
+func newSilentWsServer(t *testing.T, connections *int32) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		atomic.AddInt32(connections, 1)
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStalledFeedReconnects(t *testing.T) {
+	var connections int32
+	server := newSilentWsServer(t, &connections)
+
+	app := newTestApp(t, "")
+	app.WebSocketUri = "ws" + strings.TrimPrefix(server.URL, "http")
+	app.WsReadTimeoutSeconds = 1
+	app.wsDialer = websocket.DefaultDialer
+
+	input, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.StartWebSocket([]string{"BTC-USD"}, 1, bufio.NewReader(input), "")
+	}()
+
+	deadline := time.After(10 * time.Second)
+	for atomic.LoadInt32(&connections) < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected a reconnect after a stalled read, got %d connection(s)", atomic.LoadInt32(&connections))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	writer.Write([]byte(SelectExit + "\n"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartWebSocket did not return after exit")
+	}
+}
+
+func TestInterruptStreamUnblocksRead(t *testing.T) {
+	var connections int32
+	server := newSilentWsServer(t, &connections)
+
+	app := newTestApp(t, "")
+	app.WebSocketUri = "ws" + strings.TrimPrefix(server.URL, "http")
+	app.WsReadTimeoutSeconds = 30
+	app.wsDialer = websocket.DefaultDialer
+
+	input, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.StartWebSocket([]string{"BTC-USD"}, 1, bufio.NewReader(input), "")
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for atomic.LoadInt32(&connections) < 1 {
+		select {
+		case <-deadline:
+			t.Fatal("stream never connected")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	if !app.InterruptStream() {
+		t.Fatal("InterruptStream reported no running stream")
+	}
+	// Consume the "press Enter" prompt the stream shows after an interrupt.
+	writer.Write([]byte("\n"))
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("StartWebSocket still blocked in ReadMessage after InterruptStream")
+	}
+}
+
+func TestWsSign(t *testing.T) {
+	message := wsSignatureMessage(ChannelL2, "test-key", "svc-account", "1700000000", "BTC-USDETH-USD")
+	if want := "l2_datatest-keysvc-account1700000000BTC-USDETH-USD"; message != want {
+		t.Fatalf("wsSignatureMessage() = %q, want %q", message, want)
+	}
+
+	got := wsSign(ChannelL2, "test-key", "test-secret", "svc-account", "BTC-USDETH-USD", "1700000000")
+	if want := "4HbeIKGTxbSLs/jgRsESbFFkKbz8yEbyaYUURiUMRWE="; got != want {
+		t.Errorf("wsSign() = %q, want %q", got, want)
+	}
+}